@@ -3,14 +3,38 @@ package main
 import (
 	"os"
 	"fmt"
+	"flag"
+	"bufio"
+	"bytes"
 	"strings"
+	"unicode"
 	"encoding/json"
 	"github.com/goplus/lib/c"
+	cos "github.com/goplus/lib/c/os"
 	"github.com/goplus/lib/py"
 	"github.com/goplus/lib/py/inspect"
 	"github.com/goplus/llpyg/symbol"
+	_ "unsafe"
 )
 
+//go:linkname SequenceList C.PySequence_List
+func SequenceList(o *py.Object) *py.Object
+
+//go:linkname GetItem C.PyObject_GetItem
+func GetItem(o *py.Object, key *py.Object) *py.Object
+
+//go:linkname Repr C.PyObject_Repr
+func Repr(o *py.Object) *py.Object
+
+//go:linkname IncRef C.Py_IncRef
+func IncRef(o *py.Object)
+
+//go:linkname SysGetObject C.PySys_GetObject
+func SysGetObject(name *c.Char) *py.Object
+
+//go:linkname SysSetObject C.PySys_SetObject
+func SysSetObject(name *c.Char, v *py.Object) c.Int
+
 var pyFuncTypes = map[string]bool{
 	"ufunc":                      true,
 	"method":                     true,
@@ -20,22 +44,78 @@ var pyFuncTypes = map[string]bool{
 	"_ArrayFunctionDispatcher":   true,
 }
 
-func extractSignatureFromDoc(doc, funcName string) string {
-	lines := strings.SplitN(doc, "\n\n", 2)
-	if len(lines) == 0 {
-		return ""
+// types of class members found in the class __dict__
+var pyMethodTypes = map[string]bool{
+	"function":                  true,
+	"method_descriptor":         true,
+	"cython_function_or_method": true,
+}
+
+var pyClassMethodTypes = map[string]bool{
+	"classmethod":            true,
+	"classmethod_descriptor": true,
+}
+
+var pyStaticMethodTypes = map[string]bool{
+	"staticmethod":               true,
+	"builtin_function_or_method": true,
+}
+
+// signatures of the leading lines of doc, one per call form, e.g.
+//
+//	range(stop) -> range object
+//	range(start, stop[, step]) -> range object
+//
+// gives (stop) -> range object and (start, stop[, step]) -> range object.
+func extractSignaturesFromDoc(doc, funcName string) (sigs []string) {
+	lines := strings.Split(strings.TrimLeft(doc, "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		pos := signatureStart(line, funcName)
+		if pos < 0 {
+			if len(sigs) > 0 && (strings.HasPrefix(lines[i], " ") || strings.HasPrefix(lines[i], "\t")) {
+				continue // indented description of the previous call form
+			}
+			break
+		}
+		// a long signature may wrap over lines
+		for bracketDepth(line) > 0 && i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+			i++
+			line += " " + strings.TrimSpace(lines[i])
+		}
+		sigs = append(sigs, strings.Join(strings.Fields(line[pos:]), " "))
 	}
-	firstLine := strings.TrimSpace(lines[0])
-	if !strings.HasPrefix(firstLine, funcName+"(") {
-		return ""
+	return
+}
+
+// offset of ( if line starts with funcName(, or a qualified name like
+// D.get(, or else -1
+func signatureStart(line, funcName string) int {
+	pos := strings.Index(line, funcName+"(")
+	if pos < 0 {
+		return -1
 	}
-	idx := strings.Index(firstLine, "(")
-	if idx == -1 {
-		return ""
+	for _, c := range line[:pos] {
+		if c != '.' && c != '_' && !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			return -1
+		}
 	}
-	params := firstLine[idx:]
-	fields := strings.Fields(params)
-	return strings.Join(fields, " ")
+	if pos > 0 && line[pos-1] != '.' {
+		return -1
+	}
+	return pos + len(funcName)
+}
+
+func bracketDepth(s string) (depth int) {
+	for _, c := range s {
+		switch c {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		}
+	}
+	return
 }
 
 func getSignature(val *py.Object, sym *symbol.Symbol) string {
@@ -43,31 +123,192 @@ func getSignature(val *py.Object, sym *symbol.Symbol) string {
 	if val.Callable() == 0 {
 		return ""
 	}
+	sym.Sigs = extractSignaturesFromDoc(sym.Doc, sym.Name)
 	// get signature from inspect
 	sigFromInspect := inspect.Signature(val)
-	if sigFromInspect != nil {
+	if sigFromInspect == nil {
+		py.ErrClear() // no signature, e.g. ValueError of builtins
+	} else {
 		sig := c.GoString(sigFromInspect.Str().CStr())
 		if sig != "(*args, **kwargs)" {
+			sym.Params, sym.Return = getParams(sigFromInspect)
 			return sig
 		}
 	}
 	// get signature from doc
-	sigFromDoc := extractSignatureFromDoc(sym.Doc, sym.Name)
-	if sigFromDoc != "" {
-		return sigFromDoc
+	if len(sym.Sigs) > 0 {
+		return sym.Sigs[0]
 	}
 	// Paradigms
-	if pyFuncTypes[sym.Type] {
+	if pyFuncTypes[sym.Type] || isMethodType(sym.Type) {
 		return "(*args, **kwargs)"
 	}
 	return ""
 }
 
+// structured parameters and return annotation of an inspect.Signature
+func getParams(sig *py.Object) (params []*symbol.Param, ret string) {
+	inspectMod := py.ImportModule(c.Str("inspect"))
+	empty := inspectMod.GetAttrString(c.Str("Parameter")).GetAttrString(c.Str("empty"))
+	formatAnnotation := inspectMod.GetAttrString(c.Str("formatannotation"))
+	format := func(annotation *py.Object) string {
+		if annotation == nil || annotation == empty {
+			return ""
+		}
+		str := formatAnnotation.CallOneArg(annotation)
+		if str == nil {
+			return ""
+		}
+		return c.GoString(str.CStr())
+	}
+	params = make([]*symbol.Param, 0)
+	values := SequenceList(sig.GetAttrString(c.Str("parameters")).CallMethod(c.Str("values"), nil))
+	if values == nil {
+		return params, ""
+	}
+	for i, n := 0, values.ListLen(); i < n; i++ {
+		p := values.ListItem(i)
+		param := &symbol.Param{
+			Name:       c.GoString(p.GetAttrString(c.Str("name")).CStr()),
+			Kind:       c.GoString(p.GetAttrString(c.Str("kind")).GetAttrString(c.Str("name")).CStr()),
+			Annotation: format(p.GetAttrString(c.Str("annotation"))),
+		}
+		if defVal := p.GetAttrString(c.Str("default")); defVal != nil && defVal != empty {
+			if repr := Repr(defVal); repr != nil {
+				param.Default = c.GoString(repr.CStr())
+			}
+		}
+		params = append(params, param)
+	}
+	return params, format(sig.GetAttrString(c.Str("return_annotation")))
+}
+
+// types of variables whose repr is a python literal
+var pyLiteralTypes = map[string]bool{
+	"int":      true,
+	"float":    true,
+	"complex":  true,
+	"bool":     true,
+	"str":      true,
+	"bytes":    true,
+	"NoneType": true,
+}
+
+func isMethodType(typ string) bool {
+	return pyMethodTypes[typ] || pyClassMethodTypes[typ] || pyStaticMethodTypes[typ]
+}
+
+func getDoc(val *py.Object) string {
+	doc := val.GetAttrString(c.Str("__doc__"))
+	if doc != nil && doc.IsTrue() == 1 {
+		return c.GoString(doc.Str().CStr())
+	}
+	return ""
+}
+
+// full name of a class, e.g. builtins.object
+func getClassName(cls *py.Object) string {
+	qualname := cls.GetAttrString(c.Str("__qualname__"))
+	if qualname == nil {
+		return ""
+	}
+	name := c.GoString(qualname.Str().CStr())
+	mod := cls.GetAttrString(c.Str("__module__"))
+	if mod == nil || mod.IsTrue() != 1 {
+		return name
+	}
+	return c.GoString(mod.Str().CStr()) + "." + name
+}
+
+func dumpClass(cls *py.Object, name string) *symbol.Class {
+	clsInstance := &symbol.Class{
+		Name: name,
+		Doc:  getDoc(cls),
+	}
+	// base classes
+	bases := cls.GetAttrString(c.Str("__bases__"))
+	if bases != nil {
+		for i, n := 0, bases.TupleLen(); i < n; i++ {
+			if base := getClassName(bases.TupleItem(i)); base != "" {
+				clsInstance.Bases = append(clsInstance.Bases, base)
+			}
+		}
+	}
+	// members defined by the class itself, inherited ones belong to the bases
+	dict := cls.GetAttrString(c.Str("__dict__"))
+	if dict == nil {
+		return clsInstance
+	}
+	keys := SequenceList(dict)
+	if keys == nil {
+		return clsInstance
+	}
+	for i, n := 0, keys.ListLen(); i < n; i++ {
+		key := keys.ListItem(i)
+		member := GetItem(dict, key)
+		if member == nil {
+			py.ErrClear()
+			continue
+		}
+		sym := &symbol.Symbol{}
+		sym.Name = c.GoString(key.CStr())
+		sym.Type = c.GoString(member.Type().TypeName().CStr())
+		if !isMethodType(sym.Type) {
+			continue
+		}
+		// attribute lookup unwraps classmethod and staticmethod objects
+		val := cls.GetAttr(key)
+		if val == nil {
+			py.ErrClear()
+			continue
+		}
+		sym.Doc = getDoc(val)
+		sym.Sig = getSignature(val, sym)
+		switch {
+		case pyMethodTypes[sym.Type]:
+			clsInstance.Methods = append(clsInstance.Methods, sym)
+		case pyClassMethodTypes[sym.Type]:
+			clsInstance.ClassMethods = append(clsInstance.ClassMethods, sym)
+		case pyStaticMethodTypes[sym.Type]:
+			clsInstance.StaticMethods = append(clsInstance.StaticMethods, sym)
+		}
+	}
+	return clsInstance
+}
+
+// redirect python's sys.stdout to sys.stderr, so that libraries
+// printing banners at import don't corrupt the output
+func redirectStdout() (restore func()) {
+	stdout := SysGetObject(c.Str("stdout"))
+	IncRef(stdout)
+	SysSetObject(c.Str("stdout"), SysGetObject(c.Str("stderr")))
+	return func() {
+		SysSetObject(c.Str("stdout"), stdout)
+		stdout.DecRef()
+	}
+}
+
+// the output channel: the file if given, or else the original stdout,
+// with fd 1 redirected to stderr for prints of C extensions
+func openOutput(file string) (*os.File, error) {
+	if file != "" {
+		return os.Create(file)
+	}
+	fd := cos.Dup(1)
+	if fd < 0 {
+		return nil, fmt.Errorf("failed to dup stdout")
+	}
+	cos.Dup2(2, 1)
+	return os.NewFile(uintptr(fd), "stdout"), nil
+}
+
 // moduleName: Python module name
 func pydump(moduleName string) (*symbol.Module, error) {
+	defer redirectStdout()()
 	// import module
 	mod := py.ImportModule(c.AllocaCStr(moduleName))
 	if mod == nil {
+		py.ErrClear()
 		return nil, fmt.Errorf("failed to import module %s", moduleName)
 	}
 	// get dict, python list Object
@@ -79,37 +320,97 @@ func pydump(moduleName string) (*symbol.Module, error) {
 	modInstance := &symbol.Module{
 		Name: moduleName,
 	}
+	if file := mod.GetAttrString(c.Str("__file__")); file == nil {
+		py.ErrClear()
+	} else if c.GoString(file.Type().TypeName().CStr()) == "str" { // None for namespace packages
+		modInstance.File = c.GoString(file.CStr())
+	}
 	// get symbols
 	for i, n := 0, keys.ListLen(); i < n; i++ {
 		key := keys.ListItem(i)
 		val := mod.GetAttr(key)
 		if val == nil {
+			py.ErrClear()
 			continue
 		}
 		// define symbol
 		sym := &symbol.Symbol{}
 		sym.Name = c.GoString(key.CStr())
-		sym.Type = c.GoString(val.Type().TypeName().CStr())
-		doc := val.GetAttrString(c.Str("__doc__"))
-		if doc != nil && doc.IsTrue() == 1 {
-			sym.Doc = c.GoString(doc.Str().CStr())
+		// classes
+		if inspect.Isclass(val).IsTrue() == 1 {
+			modInstance.Classes = append(modInstance.Classes, dumpClass(val, sym.Name))
+			continue
 		}
+		sym.Type = c.GoString(val.Type().TypeName().CStr())
 		// functions
 		if pyFuncTypes[sym.Type] {
+			sym.Doc = getDoc(val)
 			sym.Sig = getSignature(val, sym)
 			modInstance.Functions = append(modInstance.Functions, sym)
+			continue
+		}
+		// variables, submodules are not
+		if val.Callable() == 0 && inspect.Ismodule(val).IsTrue() != 1 {
+			if pyLiteralTypes[sym.Type] {
+				if repr := Repr(val); repr != nil {
+					sym.Repr = c.GoString(repr.CStr())
+				}
+			}
+			modInstance.Variables = append(modInstance.Variables, sym)
 		}
-		// TODO: variables, classes, etc.
 	}
 	return modInstance, nil
 }
 
+// long-running mode, dumps the modules requested on stdin
+// with the line-delimited JSON protocol of symbol.DumpRequest
+func serve(out *os.File) {
+	reader := bufio.NewReader(os.Stdin)
+	encoder := json.NewEncoder(out)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var req symbol.DumpRequest
+			var resp symbol.DumpResponse
+			if e := json.Unmarshal(line, &req); e != nil {
+				resp.Error = fmt.Sprintf("invalid request: %v", e)
+			} else {
+				resp.Module = req.Module
+				resp.Result, e = pydump(req.Module)
+				if e != nil {
+					resp.Error = e.Error()
+				}
+			}
+			if e := encoder.Encode(&resp); e != nil {
+				fmt.Fprintf(os.Stderr, "failed to marshal json: %v\n", e)
+				os.Exit(1)
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: pydump <py_module_name>")
+	worker := flag.Bool("worker", false, "serve dump requests on stdin")
+	outFile := flag.String("o", "", "write the JSON to this file instead of stdout")
+	flag.Parse()
+	if !*worker && flag.NArg() < 1 {
+		fmt.Println("Usage: pydump [-o <file>] [-worker] <py_module_name>")
+		return
+	}
+	out, err := openOutput(*outFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	defer out.Close()
+	if *worker {
+		serve(out)
 		return
 	}
-	moduleName := os.Args[1]
+	moduleName := flag.Arg(0)
 	mod, err := pydump(moduleName)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s\n", err)
@@ -121,5 +422,5 @@ func main() {
 		fmt.Fprintf(os.Stderr, "failed to marshal json: %v\n", err)
 		os.Exit(1)
 	}
-	fmt.Println(string(data))
+	fmt.Fprintln(out, string(data))
 }
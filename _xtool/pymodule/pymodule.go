@@ -5,16 +5,28 @@ import (
 	"os"
 	"fmt"
 	"flag"
+	"bytes"
+	"time"
+	"context"
+	"errors"
+	"os/exec"
 	"strings"
 	_ "unsafe"
 	"encoding/json"
 	"github.com/goplus/lib/c"
+	cos "github.com/goplus/lib/c/os"
 	"github.com/goplus/lib/py"
 )
 
 //go:linkname SequenceList C.PySequence_List
 func SequenceList(o *py.Object) *py.Object
 
+//go:linkname SysGetObject C.PySys_GetObject
+func SysGetObject(name *c.Char) *py.Object
+
+//go:linkname SysSetObject C.PySys_SetObject
+func SysSetObject(name *c.Char, v *py.Object) c.Int
+
 
 
 type library struct {
@@ -22,6 +34,27 @@ type library struct {
 	LibVersion  string 		`json:"libVersion"`
 	Depth 		int  		`json:"depth"`
 	Modules 	[]string 	`json:"modules"`
+	Failed 		[]*failure 	`json:"failed,omitempty"`	// modules that failed to import
+
+	timeout 	time.Duration
+}
+
+// a module whose import failed, crashed or timed out
+type failure struct {
+	Module 		string 		`json:"module"`
+	Reason 		string 		`json:"reason"`
+	Stderr 		string 		`json:"stderr,omitempty"`	// tail of stderr
+}
+
+// result of importing a module in a child process
+type probeResult struct {
+	Version 	string 		`json:"version"`
+	SubModules 	[]subModule `json:"subModules"`
+}
+
+type subModule struct {
+	Name 		string 		`json:"name"`
+	IsPkg 		bool 		`json:"isPkg"`
 }
 
 
@@ -39,52 +72,137 @@ func getModuleName(libName string) string {
     return libName
 }
 
+// import module in a child process, so that a crashing or hanging import
+// is reported in pkg.Failed instead of killing the whole run
+func (pkg *library) importModule(moduleName string) *probeResult {
+	ctx := context.Background()
+	if pkg.timeout > 0 { // no timeout if 0
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, pkg.timeout)
+		defer cancel()
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, os.Args[0], "-probe", moduleName)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err == nil {
+		var res probeResult
+		if err = json.Unmarshal(stdout.Bytes(), &res); err == nil {
+			return &res
+		}
+	}
+	reason := err.Error()
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		reason = fmt.Sprintf("timeout after %ds", int(pkg.timeout.Seconds()))
+	case errors.As(err, &exitErr) && exitErr.Exited() && stderr.Len() > 0:
+		reason = lastLine(stderr.String())	// e.g. ModuleNotFoundError: No module named 'x'
+	}
+	pkg.Failed = append(pkg.Failed, &failure{
+		Module: moduleName,
+		Reason: reason,
+		Stderr: tail(stderr.String(), 20),
+	})
+	return nil
+}
+
 func (pkg *library) getModules(moduleName string, depth int) {
 	if depth > pkg.Depth {
 		return
 	}
-	mod := py.ImportModule(c.AllocaCStr(moduleName))
+	mod := pkg.importModule(moduleName)
 	if mod == nil {
 		return
 	}
 	pkg.Modules = append(pkg.Modules, moduleName)
-	if depth == pkg.Depth {
-		return
+	if depth == 1 {
+		pkg.LibVersion = mod.Version
 	}
-	pyPath := mod.GetAttrString(c.Str("__path__"))
-	if pyPath == nil {
+	if depth == pkg.Depth {
 		return
 	}
-	pkgUtil := py.ImportModule(c.Str("pkgutil"))
-	iterModules := pkgUtil.GetAttrString(c.Str("iter_modules"))
-	iter := iterModules.Call(py.Tuple(pyPath), nil)
-	subModules := SequenceList(iter)
-	for i := 0; i < subModules.ListLen(); i++ {
-		subModule := subModules.ListItem(i)
-		name := subModule.TupleItem(1)
-		nameStr := c.GoString(name.CStr())
+	for _, subModule := range mod.SubModules {
+		nameStr := subModule.Name
 		if strings.HasPrefix(nameStr, "test") || strings.HasPrefix(nameStr, "_") {
 			continue
 		}
 		subModuleName := moduleName + "." + nameStr
-		isPkg := subModule.TupleItem(2)
-		if isPkg.IsTrue() == 1 {
+		if subModule.IsPkg {
 			pkg.getModules(subModuleName, depth+1)
-		} else {
-			subMod := py.ImportModule(c.AllocaCStr(subModuleName))
-			if subMod == nil {
-				continue
-			}
+		} else if pkg.importModule(subModuleName) != nil {
 			pkg.Modules = append(pkg.Modules, subModuleName)
 		}
 	}
 }
 
+// child process: import module, print its version and submodules.
+// Prints of the module at import go to stderr, python's by redirecting
+// sys.stdout, C extensions' by redirecting fd 1.
+func probe(moduleName string) {
+	fd := cos.Dup(1)
+	cos.Dup2(2, 1)
+	out := os.NewFile(uintptr(fd), "stdout")
+	SysSetObject(c.Str("stdout"), SysGetObject(c.Str("stderr")))
+	mod := py.ImportModule(c.AllocaCStr(moduleName))
+	if mod == nil {
+		py.ErrPrint()
+		os.Exit(1)
+	}
+	res := probeResult{SubModules: []subModule{}}
+	version := mod.GetAttrString(c.Str("__version__"))
+	if version != nil {
+		res.Version = c.GoString(version.Str().CStr())
+	}
+	py.ErrClear()
+	pyPath := mod.GetAttrString(c.Str("__path__"))
+	if pyPath != nil {
+		pkgUtil := py.ImportModule(c.Str("pkgutil"))
+		iterModules := pkgUtil.GetAttrString(c.Str("iter_modules"))
+		iter := iterModules.Call(py.Tuple(pyPath), nil)
+		subModules := SequenceList(iter)
+		for i := 0; i < subModules.ListLen(); i++ {
+			item := subModules.ListItem(i)
+			res.SubModules = append(res.SubModules, subModule{
+				Name:  c.GoString(item.TupleItem(1).CStr()),
+				IsPkg: item.TupleItem(2).IsTrue() == 1,
+			})
+		}
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to marshal json: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintln(out, string(data))
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return lines[len(lines)-1]
+}
+
+func tail(s string, n int) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
 func main() {
 	depth := flag.Int("d", 1, "extract depth")
+	timeout := flag.Int("timeout", 120, "import timeout of each module in seconds, 0 for none")
+	probeModule := flag.String("probe", "", "import the module and print its submodules")
+	outFile := flag.String("o", "", "write the JSON to this file instead of stdout")
 	flag.Parse()
+	if *probeModule != "" {
+		probe(*probeModule)
+		return
+	}
 	if flag.NArg() < 1 {
-        fmt.Fprintln(os.Stderr, "Usage: pymodule [-d <depth>] <libraryName>")
+        fmt.Fprintln(os.Stderr, "Usage: pymodule [-d <depth>] [-timeout <seconds>] [-o <file>] <libraryName>")
         os.Exit(1)
     }
 	libraryName := flag.Arg(0)
@@ -92,23 +210,30 @@ func main() {
 		LibName: libraryName,
 		Depth: *depth,
 		Modules: []string{},
+		timeout: time.Duration(*timeout) * time.Second,
 	}
 	moduleName := getModuleName(libraryName)
-	mod := py.ImportModule(c.AllocaCStr(moduleName))
-	if mod == nil {
-		fmt.Fprintf(os.Stderr, "%s is not installed or not found\n", libraryName)
+	pkg.getModules(moduleName, 1)
+	if len(pkg.Modules) == 0 {
+		if len(pkg.Failed) > 0 {
+			fmt.Fprintf(os.Stderr, "%s is not installed or not found: %s\n", libraryName, pkg.Failed[0].Reason)
+		} else {
+			fmt.Fprintf(os.Stderr, "no module of %s imported with depth %d\n", libraryName, pkg.Depth)
+		}
 		os.Exit(1)
 	}
-    version := mod.GetAttrString(c.Str("__version__"))
-	if version != nil {
-		pkg.LibVersion = c.GoString(version.CStr())
-	}
-	pkg.getModules(moduleName, 1)
 	data, err := json.MarshalIndent(pkg, "", "  ")
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to marshal json: %v\n", err)
 		os.Exit(1)
 	}
+	if *outFile != "" {
+		err = os.WriteFile(*outFile, append(data, '\n'), 0644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write %s: %v\n", *outFile, err)
+			os.Exit(1)
+		}
+		return
+	}
 	fmt.Println(string(data))
 }
-
@@ -11,10 +11,14 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	_ "github.com/goplus/lib/py"
 	"github.com/goplus/llpyg/tool/pyenv"
 	"github.com/goplus/llpyg/tool/pygen"
+	"github.com/goplus/llpyg/tool/pyinspect"
+	"github.com/goplus/llpyg/tool/pysrc"
+	"github.com/goplus/llpyg/tool/pystub"
 )
 
 type Args struct {
@@ -22,6 +26,13 @@ type Args struct {
 	ModName   string
 	ModDepth  int
 	Kwarg     string	// llpyg.cfg or pythonLibName
+	DumpDir   string	// saved symbol.Module JSON files
+	Python    string	// python interpreter of the CPython backend
+	Timeout   int		// import timeout of each module in seconds
+	Stubs     []string	// stub dirs, e.g. a typeshed checkout
+	Sources   []string	// dirs of python sources to analyze if import fails
+	DocSummary bool		// keep only the summary paragraph of docstrings
+	Typed     bool		// also generate typed wrappers of annotated functions
 }
 
 type Config struct {
@@ -35,30 +46,72 @@ type library struct {
 	LibVersion 	string 		`json:"libVersion"`
 	Depth   	int      	`json:"depth"`
 	Modules 	[]string 	`json:"modules"`
+	Failed  	[]failure	`json:"failed,omitempty"`
+}
+
+// a module that failed to import, crashed or timed out in pymodule
+type failure struct {
+	Module 	string 	`json:"module"`
+	Reason 	string 	`json:"reason"`
+	Stderr 	string 	`json:"stderr,omitempty"`
 }
 
 func main() {
 	var cfg Config
+	var failed []failure // modules skipped by pymodule
 
 	// parse args
 	runMode, args := parseArgs()
 
 	// prepare python env
 	pyenv.Prepare()
+	// sources of static analysis: -src, then $PYTHONPATH and site-packages
+	args.Sources = append(args.Sources, pysrc.DefaultPaths()...)
 
 	// get config
 	switch runMode {
 	case "cmd":
-		cfg = genConfig(args)
+		cfg, failed = genConfig(args)
 	case "cfg":
 		cfg = readConfig(args.Kwarg)   		// cfgPath
+	case "dump":
+		cfg = genConfigFromDump(args)
 	}
 
 	// init work dir
 	initWorkDir(&args, cfg)
 
 	// LLGo Bindings generation
-	generateFromConfig(cfg, args.OutputDir)
+	// one pydump worker imports each package once for the whole run
+	var dumper pygen.Dumper
+	switch {
+	case args.DumpDir != "":
+		dumper = &pygen.DirDumper{Dir: args.DumpDir}
+	case useCPython(args, "pydump"):
+		worker := pyinspect.NewWorkerDumper(args.Python)
+		worker.Timeout = time.Duration(args.Timeout) * time.Second
+		defer worker.Close()
+		dumper = worker
+	default:
+		worker := &pygen.WorkerDumper{Timeout: time.Duration(args.Timeout) * time.Second}
+		defer worker.Close()
+		dumper = worker
+	}
+	// modules that fail to import are analyzed statically if pure python
+	if args.DumpDir == "" {
+		dumper = &pysrc.Dumper{Dumper: dumper, Paths: args.Sources}
+	}
+	// signatures of .pyi stubs are preferred over the ones from docs
+	dumper = &pystub.Dumper{Dumper: dumper, Paths: args.Stubs}
+	report := &pygen.Report{}
+	generateFromConfig(cfg, args.OutputDir, args.ModName, dumper, &pygen.Options{DocSummary: args.DocSummary, Typed: args.Typed, Report: report})
+	for _, f := range failed {
+		report.Add(&pygen.ModuleReport{Module: f.Module, Reason: pygen.ReasonImport + ": " + f.Reason})
+	}
+	reportFile := filepath.Join(args.OutputDir, "llpyg-report.json")
+	if err := report.WriteFile(reportFile); err != nil {
+		log.Fatalf("error: failed to write report %s: %v\n", reportFile, err)
+	}
 
 	// tidy go module
 	goModTidy(args.OutputDir)
@@ -71,12 +124,21 @@ func parseArgs() (runMode string, args Args) {
 	output := flag.String("o", "./out", "Output dir")
 	modName := flag.String("mod", "", "Generate Go Bindings module name")
 	modDepth := flag.Int("d", 1, "Extract module depth")
+	dumpDir := flag.String("from-dump", "", "Generate from saved symbol JSON files in dir")
+	python := flag.String("python", "", "Use the CPython backend with this interpreter instead of pydump and pymodule")
+	timeout := flag.Int("timeout", 120, "Import timeout of each module in seconds, 0 for none")
+	stubs := flag.String("stubs", "", "Dirs of .pyi stubs, e.g. a typeshed checkout, separated by "+string(os.PathListSeparator))
+	sources := flag.String("src", "", "Dirs of python sources to analyze statically if a module fails to import, separated by "+string(os.PathListSeparator))
+	docSummary := flag.Bool("doc-summary", false, "Keep only the summary paragraph of docstrings in Go docs")
+	typed := flag.Bool("typed", false, "Also generate wrappers with Go types of functions with simple annotations, e.g. FuncTyped(a int64) (string, error)")
 	flag.Parse()
 
-	if flag.NArg() < 1 {
+	if flag.NArg() < 1 && *dumpDir == "" {
 		fmt.Fprintln(os.Stderr, "Input error: Usage")
 		fmt.Fprintln(os.Stderr, "  llpyg [-o outputDir] [-mod modName] [-d modDepth] pythonLibName")
 		fmt.Fprintln(os.Stderr, "  llpyg [-o outputDir] [-mod modName] llpyg.cfg")
+		fmt.Fprintln(os.Stderr, "  llpyg [-o outputDir] [-mod modName] -from-dump dumpDir [pythonLibName | llpyg.cfg]")
+		fmt.Fprintln(os.Stderr, "  llpyg [-python python3] ... uses python3 instead of the LLGo-built pydump and pymodule")
 		os.Exit(1)
 	}
 	absOutput, err := filepath.Abs(*output)
@@ -88,31 +150,140 @@ func parseArgs() (runMode string, args Args) {
 		ModName:   *modName,
 		ModDepth:  *modDepth,
 		Kwarg:     flag.Arg(0),		// pythonLibName or cfgPath
+		Python:    *python,
+		Timeout:   *timeout,
+		DocSummary: *docSummary,
+		Typed:     *typed,
+	}
+	args.Stubs = absDirs(*stubs, "stubs")
+	args.Sources = absDirs(*sources, "src")
+	if *dumpDir != "" {
+		// the work dir changes during generation
+		absDumpDir, err := filepath.Abs(*dumpDir)
+		if err != nil {
+			log.Fatalf("error: failed to resolve dump path '%s': %v\n", *dumpDir, err)
+		}
+		args.DumpDir = absDumpDir
 	}
 	if strings.HasSuffix(args.Kwarg, ".cfg") {
 		return "cfg", args
 	}
+	if args.DumpDir != "" {
+		return "dump", args
+	}
 	return "cmd", args
 }
 
-// get modules info from pymodule
-func genConfig(args Args) (cfg Config) {
-	lib, err := pymodule(args.Kwarg, args.ModDepth)
+// absolute dirs of a path list
+func absDirs(list, what string) (dirs []string) {
+	for _, dir := range filepath.SplitList(list) {
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			log.Fatalf("error: failed to resolve %s path '%s': %v\n", what, dir, err)
+		}
+		dirs = append(dirs, absDir)
+	}
+	return
+}
+
+// get modules info from pymodule, with the modules that failed to import
+// and can't be analyzed statically
+func genConfig(args Args) (cfg Config, failed []failure) {
+	var lib library
+	var err error
+	if useCPython(args, "pymodule") {
+		lib, err = pymoduleFromPython(args.Python, args.Kwarg, args.ModDepth, args.Timeout)
+	} else {
+		lib, err = pymodule(args.Kwarg, args.ModDepth, args.Timeout)
+	}
 	if err != nil {
-		log.Fatal(err)
+		// a pure python package that fails to import can be analyzed statically
+		modules := pysrc.Modules(args.Kwarg, args.ModDepth, args.Sources)
+		if modules == nil {
+			log.Fatal(err)
+		}
+		log.Printf("%v\nusing static analysis of the source of %s\n", err, args.Kwarg)
+		lib = library{LibName: args.Kwarg, Modules: modules}
+	}
+	for _, f := range lib.Failed {
+		if pysrc.Find(f.Module, args.Sources) != "" {
+			log.Printf("module %s failed to import: %s, using static analysis\n", f.Module, f.Reason)
+			lib.Modules = append(lib.Modules, f.Module)
+			continue
+		}
+		log.Printf("skip module %s: %s\n", f.Module, f.Reason)
+		failed = append(failed, f)
+	}
+	if len(lib.Modules) == 0 {
+		log.Fatalf("error: no module of %s to generate\n", lib.LibName)
 	}
 	fmt.Printf("%s %s is ready\n", lib.LibName, lib.LibVersion)
 	cfg = Config{
-		Name:    lib.Modules[0], // go package name
+		Name:    pkgName(lib.Modules[0]),
 		LibName: lib.LibName,
 		Modules: lib.Modules,
 	}
+	return cfg, failed
+}
+
+// get modules info from saved dump files: the module of the lib and its
+// submodules, the lib is the package of the first module if not given
+func genConfigFromDump(args Args) (cfg Config) {
+	modules, err := pygen.DumpModules(args.DumpDir)
+	if err != nil {
+		log.Fatalf("error: failed to read dump dir %s: %v\n", args.DumpDir, err)
+	}
+	if len(modules) == 0 {
+		log.Fatalf("error: no dump files found in %s\n", args.DumpDir)
+	}
+	libName := args.Kwarg
+	if libName == "" {
+		libName, _, _ = strings.Cut(modules[0], ".")
+	}
+	cfg, others := dumpConfig(libName, modules)
+	if cfg.Modules == nil {
+		log.Fatalf("error: no dump of module %s found in %s\n", libName, args.DumpDir)
+	}
+	if len(others) > 0 {
+		log.Printf("skip the dumps of modules not in %s: %v\n", libName, others)
+	}
 	return cfg
 }
 
-func pymodule(libName string, depth int) (lib library, err error) {
+// config of lib from the modules dumped, with the modules of other libs,
+// no modules if the one of lib is not dumped
+func dumpConfig(libName string, modules []string) (cfg Config, others []string) {
+	cfg = Config{
+		Name:    pkgName(libName),
+		LibName: libName,
+	}
+	found := false
+	for _, module := range modules {
+		switch {
+		case module == libName:
+			found = true
+			cfg.Modules = append([]string{module}, cfg.Modules...)
+		case strings.HasPrefix(module, libName+"."):
+			cfg.Modules = append(cfg.Modules, module)
+		default:
+			others = append(others, module)
+		}
+	}
+	if !found {
+		cfg.Modules = nil
+	}
+	return
+}
+
+// go package name of the lib of a root module, its last part, e.g. linalg
+// of numpy.linalg, which names the output directory too
+func pkgName(rootModule string) string {
+	return rootModule[strings.LastIndexByte(rootModule, '.')+1:]
+}
+
+func pymodule(libName string, depth, timeout int) (lib library, err error) {
 	var stdout, stderr bytes.Buffer
-	cmd := exec.Command("pymodule", "-d", strconv.Itoa(depth), libName)
+	cmd := exec.Command("pymodule", "-d", strconv.Itoa(depth), "-timeout", strconv.Itoa(timeout), libName)
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 	err = cmd.Run()
@@ -126,6 +297,31 @@ func pymodule(libName string, depth int) (lib library, err error) {
 	return lib, nil
 }
 
+// pymodule of the CPython backend
+func pymoduleFromPython(python, libName string, depth, timeout int) (lib library, err error) {
+	out, err := pyinspect.Modules(python, libName, depth, timeout)
+	if err != nil {
+		return lib, err
+	}
+	err = json.Unmarshal(out, &lib)
+	if err != nil {
+		return lib, fmt.Errorf("unmarshal %s failed: %w", libName, err)
+	}
+	return lib, nil
+}
+
+// use the CPython backend if asked, or if the LLGo-built tool is not installed
+func useCPython(args Args, tool string) bool {
+	if args.Python != "" {
+		return true
+	}
+	if _, err := exec.LookPath(tool); err != nil {
+		log.Printf("%s not found, using the CPython backend\n", tool)
+		return true
+	}
+	return false
+}
+
 func readConfig(cfgPath string) (cfg Config) {
 	cfgFile, err := os.Open(cfgPath)
 	if err != nil {
@@ -160,25 +356,51 @@ func initWorkDir(args *Args, cfg Config) {
 	}
 }
 
-func generateFromConfig(cfg Config, outDir string) {
+func generateFromConfig(cfg Config, outDir, modName string, dumper pygen.Dumper, opts *pygen.Options) {
+	// dump all modules first, to link the docs of each to the symbols of the others
+	dumper = &pygen.CacheDumper{Dumper: dumper}
+	links := make(pygen.Links)
+	for _, moduleName := range cfg.Modules {
+		if mod, err := dumper.Dump(moduleName); err == nil {
+			links.Add(&mod, moduleToPkgPath(modName, cfg.LibName, moduleName))
+		}
+	}
+	opts.Links = links
 	for _, moduleName := range cfg.Modules {
 		fmt.Printf("Generating LLGo bindings for %s...\n", moduleName)
-		outFilePath := filepath.Join(outDir, moduleToPath(moduleName))
+		outFilePath := filepath.Join(outDir, moduleToPath(cfg.LibName, moduleName))
 		file, err := createFileWithDirs(outFilePath)
 		if err != nil {
 			log.Fatalf("error: failed to create file %s: %v\n", outFilePath, err)
 		}
 		defer file.Close()
-		pygen.GenLLGoBindings(moduleName, file)
+		pygen.GenLLGoBindingsWithOptions(dumper, moduleName, file, opts)
 	}
 }
 
+// import path of the go package of a module, e.g. numpy.random -> <modName>/random
+func moduleToPkgPath(modName, libName, moduleName string) string {
+	return strings.Join(append([]string{modName}, subModule(libName, moduleName)...), "/")
+}
+
 // module name to file path
-func moduleToPath(moduleName string) string {
+func moduleToPath(libName, moduleName string) string {
 	parts := strings.Split(moduleName, ".")
 	fileName := parts[len(parts)-1] + ".go"
-	parts = parts[1:]
-	path := strings.Join(parts, "/")
+	path := strings.Join(subModule(libName, moduleName), "/")
 	return filepath.Join(path, fileName)
 }
 
+// parts of a module name under the lib, e.g. numpy.linalg.lapack of
+// numpy.linalg -> [lapack]. Modules of a lib with another import name,
+// like sklearn of scikit-learn, are under their first part.
+func subModule(libName, moduleName string) []string {
+	if moduleName == libName {
+		return nil
+	}
+	if rest, ok := strings.CutPrefix(moduleName, libName+"."); ok {
+		return strings.Split(rest, ".")
+	}
+	return strings.Split(moduleName, ".")[1:]
+}
+
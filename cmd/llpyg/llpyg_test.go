@@ -4,6 +4,7 @@ import (
 	"flag"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -58,6 +59,78 @@ func TestParseArgs(t *testing.T) {
 				Kwarg:     "pandas",
 			},
 		},
+		{
+			name:    "dump_mode",
+			args:    []string{"-from-dump", "dump"},
+			runMode: "dump",
+			wantArgs: Args{
+				OutputDir: "./out",
+				ModName:   "",
+				ModDepth:  1,
+				Kwarg:     "",
+				DumpDir:   "dump",
+			},
+		},
+		{
+			name:    "dump_mode_with_cfg",
+			args:    []string{"-from-dump", "dump", "llpyg.cfg"},
+			runMode: "cfg",
+			wantArgs: Args{
+				OutputDir: "./out",
+				ModName:   "",
+				ModDepth:  1,
+				Kwarg:     "llpyg.cfg",
+				DumpDir:   "dump",
+			},
+		},
+		{
+			name:    "cmd_mode_with_stubs",
+			args:    []string{"-stubs", "typeshed" + string(os.PathListSeparator) + "stubs", "numpy"},
+			runMode: "cmd",
+			wantArgs: Args{
+				OutputDir: "./out",
+				ModName:   "",
+				ModDepth:  1,
+				Kwarg:     "numpy",
+				Stubs:     []string{"typeshed", "stubs"},
+			},
+		},
+		{
+			name:    "cmd_mode_with_src",
+			args:    []string{"-src", "site-packages", "numpy"},
+			runMode: "cmd",
+			wantArgs: Args{
+				OutputDir: "./out",
+				ModName:   "",
+				ModDepth:  1,
+				Kwarg:     "numpy",
+				Sources:   []string{"site-packages"},
+			},
+		},
+		{
+			name:    "cmd_mode_with_doc_summary",
+			args:    []string{"-doc-summary", "numpy"},
+			runMode: "cmd",
+			wantArgs: Args{
+				OutputDir:  "./out",
+				ModName:    "",
+				ModDepth:   1,
+				Kwarg:      "numpy",
+				DocSummary: true,
+			},
+		},
+		{
+			name:    "cmd_mode_with_typed",
+			args:    []string{"-typed", "numpy"},
+			runMode: "cmd",
+			wantArgs: Args{
+				OutputDir: "./out",
+				ModName:   "",
+				ModDepth:  1,
+				Kwarg:     "numpy",
+				Typed:     true,
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -105,4 +178,84 @@ func assertArgsEqual(t *testing.T, got, want Args) {
 	if got.Kwarg != want.Kwarg {
 		t.Errorf("unexpected Kwarg: got %q, want %q", got.Kwarg, want.Kwarg)
 	}
+	if want.DumpDir != "" {
+		wantDumpDir, err := filepath.Abs(want.DumpDir)
+		if err != nil {
+			t.Fatalf("failed to get absolute path for want.DumpDir %q: %v", want.DumpDir, err)
+		}
+		if got.DumpDir != wantDumpDir {
+			t.Errorf("unexpected DumpDir: got %q, want %q", got.DumpDir, wantDumpDir)
+		}
+	} else if got.DumpDir != "" {
+		t.Errorf("unexpected DumpDir: got %q, want empty", got.DumpDir)
+	}
+	if len(got.Stubs) != len(want.Stubs) {
+		t.Fatalf("unexpected Stubs: got %q, want %q", got.Stubs, want.Stubs)
+	}
+	for i, dir := range want.Stubs {
+		if wantDir, _ := filepath.Abs(dir); got.Stubs[i] != wantDir {
+			t.Errorf("unexpected Stubs[%d]: got %q, want %q", i, got.Stubs[i], wantDir)
+		}
+	}
+	if len(got.Sources) != len(want.Sources) {
+		t.Fatalf("unexpected Sources: got %q, want %q", got.Sources, want.Sources)
+	}
+	for i, dir := range want.Sources {
+		if wantDir, _ := filepath.Abs(dir); got.Sources[i] != wantDir {
+			t.Errorf("unexpected Sources[%d]: got %q, want %q", i, got.Sources[i], wantDir)
+		}
+	}
+	if got.DocSummary != want.DocSummary {
+		t.Errorf("unexpected DocSummary: got %v, want %v", got.DocSummary, want.DocSummary)
+	}
+	if got.Typed != want.Typed {
+		t.Errorf("unexpected Typed: got %v, want %v", got.Typed, want.Typed)
+	}
+}
+
+func TestDumpConfig(t *testing.T) {
+	modules := []string{"numpy", "numpy.linalg", "numpy.linalg.lapack", "numpyx", "scipy"}
+	cfg, others := dumpConfig("numpy", modules)
+	if cfg.Name != "numpy" || !reflect.DeepEqual(cfg.Modules, []string{"numpy", "numpy.linalg", "numpy.linalg.lapack"}) {
+		t.Fatalf("cfg = %+v", cfg)
+	}
+	if !reflect.DeepEqual(others, []string{"numpyx", "scipy"}) {
+		t.Fatalf("others = %v", others)
+	}
+	cfg, _ = dumpConfig("numpy.linalg", modules)
+	if cfg.Name != "linalg" || !reflect.DeepEqual(cfg.Modules, []string{"numpy.linalg", "numpy.linalg.lapack"}) {
+		t.Fatalf("cfg = %+v", cfg)
+	}
+	// no root module
+	if cfg, _ = dumpConfig("numpy", []string{"numpy.linalg"}); cfg.Modules != nil {
+		t.Fatalf("cfg = %+v", cfg)
+	}
+}
+
+func TestPkgName(t *testing.T) {
+	for rootModule, want := range map[string]string{"numpy": "numpy", "numpy.linalg": "linalg", "sklearn": "sklearn"} {
+		if got := pkgName(rootModule); got != want {
+			t.Fatalf("pkgName(%s) = %s, want %s", rootModule, got, want)
+		}
+	}
+}
+
+func TestModuleToPath(t *testing.T) {
+	cases := []struct {
+		libName, moduleName, path, pkgPath string
+	}{
+		{"numpy", "numpy", "numpy.go", "mod"},
+		{"numpy", "numpy.random", filepath.Join("random", "random.go"), "mod/random"},
+		{"numpy.linalg", "numpy.linalg", "linalg.go", "mod"},
+		{"numpy.linalg", "numpy.linalg.lapack", filepath.Join("lapack", "lapack.go"), "mod/lapack"},
+		{"scikit-learn", "sklearn.svm", filepath.Join("svm", "svm.go"), "mod/svm"},
+	}
+	for _, c := range cases {
+		if path := moduleToPath(c.libName, c.moduleName); path != c.path {
+			t.Fatalf("moduleToPath(%s, %s) = %s, want %s", c.libName, c.moduleName, path, c.path)
+		}
+		if pkgPath := moduleToPkgPath("mod", c.libName, c.moduleName); pkgPath != c.pkgPath {
+			t.Fatalf("moduleToPkgPath(%s, %s) = %s, want %s", c.libName, c.moduleName, pkgPath, c.pkgPath)
+		}
+	}
 }
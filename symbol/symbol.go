@@ -1,14 +1,82 @@
 package symbol
 
+// Parameter kinds, the names of inspect.Parameter.kind
+const (
+	PositionalOnly      = "POSITIONAL_ONLY"
+	PositionalOrKeyword = "POSITIONAL_OR_KEYWORD"
+	VarPositional       = "VAR_POSITIONAL"
+	KeywordOnly         = "KEYWORD_ONLY"
+	VarKeyword          = "VAR_KEYWORD"
+)
+
+type Param struct {
+	Name       string `json:"name"`
+	Kind       string `json:"kind"`
+	Annotation string `json:"annotation,omitempty"` // e.g. 'str | None'
+	Default    string `json:"default,omitempty"`    // repr of the default value
+}
+
+// DocParam is an item of a docstring section, e.g. a parameter, a return value or an exception.
+type DocParam struct {
+	Name string `json:"name,omitempty"` // without the stars of *args, empty for unnamed return values
+	Type string `json:"type,omitempty"` // as written, e.g. array_like, optional
+	Desc string `json:"desc,omitempty"`
+}
+
+// Docstring is a docstring parsed by its sections.
+type Docstring struct {
+	Style    string      `json:"style,omitempty"`    // numpydoc, google, rest, or empty for plain text
+	Summary  string      `json:"summary,omitempty"`  // first paragraph
+	Desc     string      `json:"desc,omitempty"`     // extended description and the other sections
+	Params   []*DocParam `json:"params,omitempty"`   // Parameters, Args or :param:
+	Returns  []*DocParam `json:"returns,omitempty"`  // Returns, Yields or :returns: and :rtype:
+	Raises   []*DocParam `json:"raises,omitempty"`   // Raises or :raises:, Type is the exception
+	SeeAlso  []string    `json:"seeAlso,omitempty"`  // names of related symbols
+	Notes    string      `json:"notes,omitempty"`    // Notes
+	Examples string      `json:"examples,omitempty"` // Examples, with the >>> prompts
+}
+
 type Symbol struct {
-	Name string `json:"name"`
-	Type string `json:"type"`
-	Doc  string `json:"doc"`
-	Sig  string `json:"sig"`
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`
+	Doc    string   `json:"doc"`
+	Sig    string   `json:"sig"`
+	Sigs   []string `json:"sigs,omitempty"`   // call forms documented in the doc, e.g. (stop) and (start, stop[, step]) of range
+	Params []*Param `json:"params"`           // from inspect.Signature, nil if unknown
+	Return string   `json:"return,omitempty"` // return annotation
+	Repr   string   `json:"repr,omitempty"`   // value of simple literals, e.g. 3.14
+
+	Docstring *Docstring `json:"docstring,omitempty"` // Doc parsed by pydoc
+}
+
+type Class struct {
+	Name          string    `json:"name"`          // python class name
+	Doc           string    `json:"doc"`           // class doc
+	Bases         []string  `json:"bases"`         // base classes, e.g. builtins.object
+	Methods       []*Symbol `json:"methods"`       // instance methods
+	ClassMethods  []*Symbol `json:"classMethods"`  // methods decorated with @classmethod
+	StaticMethods []*Symbol `json:"staticMethods"` // methods decorated with @staticmethod
+
+	Docstring *Docstring `json:"docstring,omitempty"` // Doc parsed by pydoc
 }
 
 type Module struct {
-	Name      string    `json:"name"`      // python module name
-	Functions []*Symbol `json:"functions"` // package functions
-	// TODO: variables, classes, etc.
+	Name      string    `json:"name"`             // python module name
+	File      string    `json:"file,omitempty"`   // __file__ of the module, to find its stubs and source
+	Functions []*Symbol `json:"functions"`        // package functions
+	Classes   []*Class  `json:"classes"`          // package classes
+	Variables []*Symbol `json:"variables"`        // package variables and constants
+	Static    bool      `json:"static,omitempty"` // found by static analysis of File, not imported
+}
+
+// Line-delimited JSON protocol of the long-running pydump worker (pydump -worker),
+// one request per line on stdin, one response per line on stdout.
+type DumpRequest struct {
+	Module string `json:"module"` // python module name
+}
+
+type DumpResponse struct {
+	Module string  `json:"module"`
+	Result *Module `json:"result,omitempty"`
+	Error  string  `json:"error,omitempty"`
 }
@@ -0,0 +1,324 @@
+package pydoc
+
+import (
+	"regexp"
+	"strings"
+	"github.com/goplus/llpyg/symbol"
+)
+
+// GoDoc converts a docstring to the text of a Go doc comment. Section
+// headers become # headings, parameters and other items become lists,
+// examples and literal blocks become code blocks, and reST roles like
+// :func:`add` become plain text, or the doc link returned by link, e.g.
+// [Add]. link returns "" for names it does not know, it may be nil.
+func GoDoc(doc string, link func(name string) string) string {
+	w := &docWriter{link: link}
+	lines := strings.Split(Clean(doc), "\n")
+	var head []string
+	var sections []section
+	style := ""
+	switch {
+	case isNumpydoc(lines):
+		style = Numpydoc
+		head, sections = numpydocSections(lines)
+	case isGoogle(lines):
+		style = Google
+		head, sections = googleSections(lines)
+	case isRest(lines):
+		d := &symbol.Docstring{Style: Rest}
+		head = parseRest(d, lines)
+		w.text(head)
+		w.items("Parameters", d.Params, paramLabel)
+		w.items("Returns", d.Returns, returnLabel)
+		w.items("Raises", d.Raises, raiseLabel)
+		return w.String()
+	default:
+		head = lines
+	}
+	w.text(head)
+	for _, sec := range sections {
+		d := &symbol.Docstring{Style: style}
+		switch sectionKinds[strings.ToLower(sec.title)] {
+		case sectionParams:
+			addSection(d, sec)
+			w.items(sec.title, d.Params, paramLabel)
+		case sectionReturns:
+			addSection(d, sec)
+			w.items(sec.title, d.Returns, returnLabel)
+		case sectionRaises:
+			addSection(d, sec)
+			w.items(sec.title, d.Raises, raiseLabel)
+		case sectionSeeAlso:
+			w.heading(sec.title)
+			for _, it := range items(sec.body) {
+				names, desc := splitColon(it.head)
+				var refs []string
+				for _, name := range strings.Split(names, ",") {
+					if name = strings.TrimSpace(name); strings.Contains(name, "`") {
+						refs = append(refs, w.inline(name))
+					} else if name != "" {
+						refs = append(refs, w.ref("", name))
+					}
+				}
+				w.item(strings.Join(refs, ", "), joinDesc(desc, it.desc))
+			}
+		default:
+			w.heading(sec.title)
+			w.text(sec.body)
+		}
+	}
+	return w.String()
+}
+
+func paramLabel(p *symbol.DocParam) string {
+	if p.Type != "" {
+		return p.Name + " (" + p.Type + ")"
+	}
+	return p.Name
+}
+
+func returnLabel(p *symbol.DocParam) string {
+	if p.Name != "" {
+		return paramLabel(p)
+	}
+	return p.Type
+}
+
+func raiseLabel(p *symbol.DocParam) string {
+	return p.Type
+}
+
+type docWriter struct {
+	link  func(name string) string
+	lines []string
+}
+
+func (w *docWriter) String() string {
+	return strings.Join(trimBlank(w.lines), "\n")
+}
+
+// a blank line before a new block
+func (w *docWriter) block() {
+	if n := len(w.lines); n > 0 && w.lines[n-1] != "" {
+		w.lines = append(w.lines, "")
+	}
+}
+
+func (w *docWriter) heading(title string) {
+	w.block()
+	w.lines = append(w.lines, "# "+strings.TrimSuffix(title, ":"), "")
+}
+
+func (w *docWriter) code(lines []string) {
+	lines = dedent(lines)
+	if len(lines) == 0 {
+		return
+	}
+	w.block()
+	for _, line := range lines {
+		if line == "" {
+			w.lines = append(w.lines, "")
+		} else {
+			w.lines = append(w.lines, "\t"+line)
+		}
+	}
+	w.lines = append(w.lines, "")
+}
+
+// a list item, the lines of a list are not separated by blank lines
+func (w *docWriter) item(label, desc string) {
+	if n := len(w.lines); n > 0 && w.lines[n-1] != "" && !strings.HasPrefix(w.lines[n-1], "  - ") {
+		w.block()
+	}
+	text := w.inline(strings.Join(strings.Fields(desc), " "))
+	switch {
+	case label == "":
+	case text == "":
+		text = label
+	default:
+		text = label + ": " + text
+	}
+	if text != "" {
+		w.lines = append(w.lines, "  - "+text)
+	}
+}
+
+func (w *docWriter) items(title string, list []*symbol.DocParam, label func(*symbol.DocParam) string) {
+	if len(list) == 0 {
+		return
+	}
+	w.heading(title)
+	for _, p := range list {
+		w.item(label(p), p.Desc)
+	}
+}
+
+var (
+	directive = regexp.MustCompile(`^\.\.\s+([\w:-]+)::\s*(.*)$`)
+	footnote  = regexp.MustCompile(`^\.\.\s+\[([^\]]+)\]\s*(.*)$`)
+	bullet    = regexp.MustCompile(`^([-*+]|\d+[.)]|#\.)\s+(.*)$`)
+)
+
+// text lines of a docstring, with paragraphs, lists, code blocks and directives
+func (w *docWriter) text(lines []string) {
+	literal := false // the next indented block is a literal block after ::
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		ind := len(line) - len(strings.TrimLeft(line, " "))
+		if trimmed == "" {
+			w.block()
+			i++
+			continue
+		}
+		switch {
+		case strings.HasPrefix(trimmed, ">>>"):
+			end := i
+			for end < len(lines) && strings.TrimSpace(lines[end]) != "" {
+				end++
+			}
+			w.code(lines[i:end])
+			i = end
+			continue
+		case strings.HasPrefix(trimmed, ".."):
+			end := indented(lines, i+1, ind)
+			body := dedent(lines[i+1 : end])
+			if m := footnote.FindStringSubmatch(trimmed); m != nil {
+				w.block()
+				w.paragraph("[" + m[1] + "] " + m[2] + " " + strings.Join(body, " "))
+			} else if m := directive.FindStringSubmatch(trimmed); m != nil {
+				w.directive(m[1], m[2], body)
+			}
+			i = end
+			continue
+		case ind > 0 && (literal || len(w.lines) == 0 || w.lines[len(w.lines)-1] == ""):
+			// an indented block after a blank line
+			end := indented(lines, i, ind-1)
+			w.code(lines[i:end])
+			i, literal = end, false
+			continue
+		}
+		if m := bullet.FindStringSubmatch(trimmed); m != nil {
+			end := indented(lines, i+1, ind)
+			text := m[2]
+			for _, next := range lines[i+1 : end] {
+				text += " " + strings.TrimSpace(next)
+			}
+			if n := len(w.lines); n > 0 && w.lines[n-1] != "" && !strings.HasPrefix(w.lines[n-1], "  ") {
+				w.block()
+			}
+			marker := m[1]
+			if marker[0] < '0' || marker[0] > '9' {
+				marker = "-"
+			}
+			w.lines = append(w.lines, "  "+marker+" "+w.inline(strings.TrimSpace(text)))
+			i = end
+			continue
+		}
+		// a paragraph line, indented continuation lines are joined
+		literal = strings.HasSuffix(trimmed, "::")
+		if literal {
+			trimmed = strings.TrimSuffix(trimmed, ":")
+			if trimmed == ":" {
+				i++
+				continue
+			}
+		}
+		if n := len(w.lines); n > 0 && strings.HasPrefix(w.lines[n-1], "  ") {
+			w.block() // after a list
+		}
+		w.paragraph(trimmed)
+		i++
+	}
+}
+
+func (w *docWriter) paragraph(text string) {
+	w.lines = append(w.lines, w.inline(strings.TrimSpace(text)))
+}
+
+// .. note:: text, .. code-block:: python, .. versionadded:: 1.2 ...
+func (w *docWriter) directive(name, args string, body []string) {
+	text := strings.TrimSpace(args + " " + strings.Join(strings.Fields(strings.Join(body, " ")), " "))
+	switch name {
+	case "code-block", "code", "sourcecode", "doctest", "testcode", "math":
+		if name == "math" && args != "" {
+			body = append([]string{args}, body...)
+		}
+		w.code(body)
+		return
+	case "versionadded":
+		text = "New in version " + strings.TrimSpace(args+". "+strings.Join(body, " "))
+	case "versionchanged":
+		text = "Changed in version " + strings.TrimSpace(args+": "+strings.Join(body, " "))
+	case "deprecated":
+		text = "Deprecated: since version " + strings.TrimSpace(args+". "+strings.Join(body, " "))
+	case "seealso":
+		text = "See also: " + text
+	case "autosummary", "toctree", "image", "figure", "plot", "only", "include", "index", "currentmodule", "module", "highlight":
+		return
+	default: // note, warning and other admonitions
+		text = strings.ToUpper(name[:1]) + name[1:] + ": " + text
+	}
+	w.block()
+	w.paragraph(strings.TrimSuffix(text, " ."))
+	w.block()
+}
+
+// end of the lines after from indented more than ind, with blank lines between
+func indented(lines []string, from, ind int) int {
+	end := from
+	for j := from; j < len(lines); j++ {
+		line := lines[j]
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if len(line)-len(strings.TrimLeft(line, " ")) <= ind {
+			break
+		}
+		end = j + 1
+	}
+	return end
+}
+
+var (
+	role   = regexp.MustCompile("``([^`]+)``|(:[\\w.:+-]+:)?`([^`]+)`_{0,2}")
+	strong = regexp.MustCompile(`\*\*([^*\s][^*]*)\*\*`)
+	target = regexp.MustCompile(`^(.*?)\s*<([^<>]+)>$`) // text <target>
+)
+
+// inline markup: double backquoted code, :role:`target`, `name` and **strong**
+func (w *docWriter) inline(text string) string {
+	text = role.ReplaceAllStringFunc(text, func(s string) string {
+		m := role.FindStringSubmatch(s)
+		if m[1] != "" {
+			return m[1]
+		}
+		return w.ref(strings.Trim(m[2], ":"), m[3])
+	})
+	return strong.ReplaceAllString(text, "$1")
+}
+
+// the text of a role, a doc link if it names a bound symbol
+func (w *docWriter) ref(role, content string) string {
+	if m := target.FindStringSubmatch(content); m != nil && m[1] != "" {
+		content = m[1] // text <target>
+		if role == "ref" || role == "doc" || role == "" {
+			return content
+		}
+	}
+	switch strings.TrimPrefix(role, "py:") {
+	case "ref", "doc", "math", "term", "abbr", "command", "file", "envvar", "sup", "sub", "samp", "kbd":
+		return content
+	}
+	name := strings.TrimPrefix(strings.TrimPrefix(content, "!"), "~")
+	display := name
+	if strings.HasPrefix(content, "~") {
+		display = name[strings.LastIndexByte(name, '.')+1:]
+	}
+	if w.link != nil {
+		if link := w.link(strings.TrimSuffix(name, "()")); link != "" {
+			return link
+		}
+	}
+	return display
+}
@@ -0,0 +1,130 @@
+package pydoc
+
+import (
+	"testing"
+)
+
+func testLink(name string) string {
+	switch name {
+	case "subtract", "numpy.multiply":
+		return "[" + name + "]"
+	}
+	return ""
+}
+
+func TestGoDocNumpydoc(t *testing.T) {
+	want := `Add arguments element-wise.
+
+Extended description.
+
+# Parameters
+
+  - x1 (array_like): The arrays to be added. If x1.shape != x2.shape, they must be broadcastable.
+  - x2 (array_like): The arrays to be added. If x1.shape != x2.shape, they must be broadcastable.
+  - out (ndarray, None, or tuple of ndarray and None, optional): A location into which the result is stored.
+  - kwargs: For other keyword-only arguments.
+
+# Returns
+
+  - add (ndarray or scalar): The sum of x1 and x2.
+
+# Raises
+
+  - ValueError: If the shapes mismatch.
+
+# See Also
+
+  - [subtract], [numpy.multiply]: Multiply arguments.
+
+# Notes
+
+Equivalent to x1 + x2.
+
+# References
+
+[1] Wikipedia.
+
+# Examples
+
+	>>> np.add(1.0, 4.0)
+	5.0`
+	if got := GoDoc(numpyDoc, testLink); got != want {
+		t.Fatalf("GoDoc:\n%s", got)
+	}
+}
+
+func TestGoDocGoogle(t *testing.T) {
+	doc := `Fetch rows.
+
+    Args:
+        table (Table): An open table.
+        keys (list[str]): Keys to fetch,
+            in order.
+
+    Returns:
+        dict: The rows.
+    `
+	want := `Fetch rows.
+
+# Args
+
+  - table (Table): An open table.
+  - keys (list[str]): Keys to fetch, in order.
+
+# Returns
+
+  - dict: The rows.`
+	if got := GoDoc(doc, nil); got != want {
+		t.Fatalf("GoDoc:\n%s", got)
+	}
+}
+
+func TestGoDocRest(t *testing.T) {
+	doc := "Send a message, see :func:`subtract` and :class:`~io.Base`.\n\n" +
+		":param str x: The ``x``.\n:returns: id\n:rtype: int"
+	want := `Send a message, see [subtract] and Base.
+
+# Parameters
+
+  - x (str): The x.
+
+# Returns
+
+  - int: id`
+	if got := GoDoc(doc, testLink); got != want {
+		t.Fatalf("GoDoc:\n%s", got)
+	}
+}
+
+func TestGoDocText(t *testing.T) {
+	doc := "Summary.\n\n" +
+		"Example::\n\n    fetch(t, ['a'])\n\n" +
+		".. versionadded:: 1.2\n" +
+		".. note:: Slow on big tables,\n   use ``fetch_many`` instead.\n" +
+		".. deprecated:: 2.0\n   Use :meth:`Table.get`.\n" +
+		".. autosummary::\n   :toctree: api\n\n" +
+		".. code-block:: python\n\n   x = 1\n\n" +
+		"- first item\n  continued\n- **second**\n\n" +
+		"See `the guide <https://example.com>`_."
+	want := `Summary.
+
+Example:
+
+	fetch(t, ['a'])
+
+New in version 1.2.
+
+Note: Slow on big tables, use fetch_many instead.
+
+Deprecated: since version 2.0. Use Table.get.
+
+	x = 1
+
+  - first item continued
+  - second
+
+See the guide.`
+	if got := GoDoc(doc, nil); got != want {
+		t.Fatalf("GoDoc:\n%s", got)
+	}
+}
@@ -0,0 +1,541 @@
+// Package pydoc parses docstrings in the numpydoc, Google and reST styles
+// into their sections, e.g. the types and descriptions of parameters.
+package pydoc
+
+import (
+	"regexp"
+	"strings"
+	"github.com/goplus/llpyg/symbol"
+)
+
+// Docstring styles
+const (
+	Numpydoc = "numpydoc"
+	Google   = "google"
+	Rest     = "rest"
+)
+
+type sectionKind int
+
+const (
+	sectionOther sectionKind = iota
+	sectionParams
+	sectionReturns
+	sectionRaises
+	sectionSeeAlso
+	sectionNotes
+	sectionExamples
+)
+
+// section titles, in lower case
+var sectionKinds = map[string]sectionKind{
+	"parameters":         sectionParams,
+	"params":             sectionParams,
+	"args":               sectionParams,
+	"arguments":          sectionParams,
+	"other parameters":   sectionParams,
+	"keyword args":       sectionParams,
+	"keyword arguments":  sectionParams,
+	"keyword parameters": sectionParams,
+	"returns":            sectionReturns,
+	"return":             sectionReturns,
+	"yields":             sectionReturns,
+	"yield":              sectionReturns,
+	"raises":             sectionRaises,
+	"raise":              sectionRaises,
+	"see also":           sectionSeeAlso,
+	"notes":              sectionNotes,
+	"note":               sectionNotes,
+	"examples":           sectionExamples,
+	"example":            sectionExamples,
+	"attributes":         sectionOther,
+	"methods":            sectionOther,
+	"other methods":      sectionOther,
+	"references":         sectionOther,
+	"receives":           sectionOther,
+	"warns":              sectionOther,
+	"warnings":           sectionOther,
+	"warning":            sectionOther,
+	"todo":               sectionOther,
+}
+
+type section struct {
+	title string
+	body  []string // dedented
+}
+
+// Parse parses a docstring. The style is detected from its section headers:
+// underlined ones for numpydoc, `Args:` and the like followed by an indented
+// body for Google, and field lists like `:param x:` for reST.
+func Parse(doc string) *symbol.Docstring {
+	lines := strings.Split(Clean(doc), "\n")
+	d := &symbol.Docstring{}
+	var head []string
+	var sections []section
+	switch {
+	case isNumpydoc(lines):
+		d.Style = Numpydoc
+		head, sections = numpydocSections(lines)
+	case isGoogle(lines):
+		d.Style = Google
+		head, sections = googleSections(lines)
+	case isRest(lines):
+		d.Style = Rest
+		head = parseRest(d, lines)
+	default:
+		head = lines
+	}
+	d.Summary, d.Desc = splitHead(head)
+	for _, sec := range sections {
+		addSection(d, sec)
+	}
+	return d
+}
+
+// Clean removes the indent of the lines after the first one, and the
+// leading and trailing blank lines, like inspect.cleandoc.
+func Clean(doc string) string {
+	lines := strings.Split(strings.ReplaceAll(doc, "\t", "        "), "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		if trimmed := strings.TrimLeft(line, " "); trimmed != "" {
+			if n := len(line) - len(trimmed); indent < 0 || n < indent {
+				indent = n
+			}
+		}
+	}
+	lines[0] = strings.TrimLeft(lines[0], " ")
+	for i := 1; i < len(lines); i++ {
+		if len(lines[i]) >= indent && indent > 0 {
+			lines[i] = lines[i][indent:]
+		} else if indent > 0 {
+			lines[i] = strings.TrimLeft(lines[i], " ") // blank
+		}
+	}
+	return strings.Join(trimBlank(lines), "\n")
+}
+
+// Annotation returns the type of a documented param without the notes
+// on it, e.g. `int, optional` -> int, `str, default: 'a'` -> str.
+func Annotation(docType string) string {
+	var parts []string
+	depth, last := 0, 0
+	for i := 0; i <= len(docType); i++ {
+		if i < len(docType) {
+			switch docType[i] {
+			case '(', '[', '{':
+				depth++
+			case ')', ']', '}':
+				depth--
+			}
+			if docType[i] != ',' || depth > 0 {
+				continue
+			}
+		}
+		part := strings.TrimSpace(docType[last:i])
+		last = i + 1
+		if lower := strings.ToLower(part); part == "" || strings.HasPrefix(lower, "optional") || strings.HasPrefix(lower, "default") {
+			continue
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// first paragraph, and the rest
+func splitHead(lines []string) (summary, desc string) {
+	lines = trimBlank(lines)
+	end := 0
+	for end < len(lines) && strings.TrimSpace(lines[end]) != "" {
+		end++
+	}
+	var words []string
+	for _, line := range lines[:end] {
+		words = append(words, strings.TrimSpace(line))
+	}
+	return strings.Join(words, " "), strings.Join(trimBlank(lines[end:]), "\n")
+}
+
+func addSection(d *symbol.Docstring, sec section) {
+	kind, ok := sectionKinds[strings.ToLower(sec.title)]
+	if !ok {
+		kind = sectionOther
+	}
+	switch kind {
+	case sectionParams:
+		for _, it := range items(sec.body) {
+			d.Params = append(d.Params, itemParams(d.Style, it)...)
+		}
+	case sectionReturns:
+		d.Returns = append(d.Returns, returns(d.Style, sec.body)...)
+	case sectionRaises:
+		for _, it := range items(sec.body) {
+			typ, desc := it.head, ""
+			if d.Style == Google {
+				typ, desc = splitColon(it.head)
+			}
+			d.Raises = append(d.Raises, &symbol.DocParam{Type: typ, Desc: joinDesc(desc, it.desc)})
+		}
+	case sectionSeeAlso:
+		for _, it := range items(sec.body) {
+			names, _ := splitColon(it.head)
+			for _, name := range strings.Split(names, ",") {
+				if name = cleanRef(name); name != "" {
+					d.SeeAlso = append(d.SeeAlso, name)
+				}
+			}
+		}
+	case sectionNotes:
+		d.Notes = appendText(d.Notes, strings.Join(sec.body, "\n"))
+	case sectionExamples:
+		d.Examples = appendText(d.Examples, strings.Join(sec.body, "\n"))
+	default:
+		d.Desc = appendText(d.Desc, sec.title+":\n"+indent(sec.body))
+	}
+}
+
+// params of an item: `x1, x2 : array_like` of numpydoc,
+// `x (int): desc` and `x: desc` of Google
+func itemParams(style string, it item) (params []*symbol.DocParam) {
+	names, typ, desc := it.head, "", ""
+	if style == Google {
+		names, desc = splitColon(it.head)
+		if open := strings.IndexByte(names, '('); open > 0 && strings.HasSuffix(names, ")") {
+			names, typ = names[:open], names[open+1:len(names)-1]
+		}
+	} else if pos := strings.Index(it.head, " :"); pos >= 0 {
+		names, typ = it.head[:pos], strings.TrimSpace(it.head[pos+2:])
+	} else if name, ok := strings.CutSuffix(it.head, ":"); ok {
+		names = name
+	}
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimLeft(strings.ReplaceAll(strings.TrimSpace(name), `\*`, "*"), "*")
+		if name != "" {
+			params = append(params, &symbol.DocParam{Name: name, Type: strings.TrimSpace(typ), Desc: joinDesc(desc, it.desc)})
+		}
+	}
+	return
+}
+
+// return values: `name : type` or `type` items of numpydoc, a `type: desc`
+// or a plain description of Google
+func returns(style string, body []string) (rets []*symbol.DocParam) {
+	if style != Google {
+		for _, it := range items(body) {
+			ret := &symbol.DocParam{Type: it.head, Desc: joinDesc("", it.desc)}
+			if pos := strings.Index(it.head, " :"); pos >= 0 {
+				ret.Name, ret.Type = strings.TrimSpace(it.head[:pos]), strings.TrimSpace(it.head[pos+2:])
+			}
+			rets = append(rets, ret)
+		}
+		return
+	}
+	if len(body) == 0 {
+		return nil
+	}
+	typ, desc := splitColon(body[0])
+	if desc == "" && !strings.HasSuffix(strings.TrimSpace(body[0]), ":") || strings.ContainsAny(stripBrackets(typ), " \t") {
+		return []*symbol.DocParam{{Desc: strings.Join(trimBlank(body), "\n")}}
+	}
+	return []*symbol.DocParam{{Type: typ, Desc: joinDesc(desc, dedent(body[1:]))}}
+}
+
+type item struct {
+	head string   // the first line
+	desc []string // the indented lines after it, dedented
+}
+
+// items of a section body, each starting at a line that is not indented
+func items(body []string) (list []item) {
+	for _, line := range body {
+		switch {
+		case strings.TrimSpace(line) == "":
+			if n := len(list); n > 0 {
+				list[n-1].desc = append(list[n-1].desc, "")
+			}
+		case line[0] != ' ' || len(list) == 0:
+			list = append(list, item{head: strings.TrimSpace(line)})
+		default:
+			list[len(list)-1].desc = append(list[len(list)-1].desc, line)
+		}
+	}
+	for i := range list {
+		list[i].desc = dedent(list[i].desc)
+	}
+	return
+}
+
+var underline = regexp.MustCompile(`^\s*(-{3,}|={3,})\s*$`)
+
+// a known title underlined by the next line
+func isNumpydoc(lines []string) bool {
+	for i := 0; i+1 < len(lines); i++ {
+		if _, ok := sectionKinds[strings.ToLower(strings.TrimSpace(lines[i]))]; ok && underline.MatchString(lines[i+1]) {
+			return true
+		}
+	}
+	return false
+}
+
+func numpydocSections(lines []string) (head []string, sections []section) {
+	var cur *section
+	for i := 0; i < len(lines); i++ {
+		title := strings.TrimSpace(lines[i])
+		if title != "" && i+1 < len(lines) && underline.MatchString(lines[i+1]) {
+			sections = append(sections, section{title: title})
+			cur = &sections[len(sections)-1]
+			i++
+			continue
+		}
+		if cur == nil {
+			head = append(head, lines[i])
+		} else {
+			cur.body = append(cur.body, lines[i])
+		}
+	}
+	for i := range sections {
+		sections[i].body = dedent(sections[i].body)
+	}
+	return
+}
+
+// a known title with a colon, not indented, followed by an indented line
+func googleTitle(lines []string, i int) string {
+	line := lines[i]
+	if line == "" || line[0] == ' ' || !strings.HasSuffix(line, ":") {
+		return ""
+	}
+	title := strings.TrimSuffix(line, ":")
+	if _, ok := sectionKinds[strings.ToLower(title)]; !ok {
+		return ""
+	}
+	for _, next := range lines[i+1:] {
+		if strings.TrimSpace(next) != "" {
+			if next[0] == ' ' {
+				return title
+			}
+			break
+		}
+	}
+	return ""
+}
+
+func isGoogle(lines []string) bool {
+	for i := range lines {
+		if googleTitle(lines, i) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func googleSections(lines []string) (head []string, sections []section) {
+	var cur *section
+	for i, line := range lines {
+		if title := googleTitle(lines, i); title != "" {
+			sections = append(sections, section{title: title})
+			cur = &sections[len(sections)-1]
+			continue
+		}
+		if cur != nil && line != "" && line[0] != ' ' {
+			cur = nil // back to the description
+		}
+		if cur == nil {
+			head = append(head, line)
+		} else {
+			cur.body = append(cur.body, line)
+		}
+	}
+	for i := range sections {
+		sections[i].body = dedent(sections[i].body)
+	}
+	return
+}
+
+// :param x: desc, :type x: int, :returns: desc, :rtype: int, :raises ValueError: desc
+var restField = regexp.MustCompile(`^:(param|parameter|arg|argument|key|keyword|kwarg|type|returns|return|rtype|raises|raise|except|exception)(\s+[^:]*)?:(.*)$`)
+
+func isRest(lines []string) bool {
+	for _, line := range lines {
+		if restField.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// parses the field lists of a reST docstring into d, returns the other lines
+func parseRest(d *symbol.Docstring, lines []string) (head []string) {
+	params := make(map[string]*symbol.DocParam)
+	var ret *symbol.DocParam
+	returns := func() *symbol.DocParam {
+		if ret == nil {
+			ret = &symbol.DocParam{}
+			d.Returns = append(d.Returns, ret)
+		}
+		return ret
+	}
+	param := func(name string) *symbol.DocParam {
+		name = strings.TrimLeft(name, "*")
+		if params[name] == nil {
+			params[name] = &symbol.DocParam{Name: name}
+			d.Params = append(d.Params, params[name])
+		}
+		return params[name]
+	}
+	var desc *string // the description continued by indented lines
+	for _, line := range lines {
+		m := restField.FindStringSubmatch(line)
+		if m == nil {
+			if desc != nil && strings.HasPrefix(line, " ") {
+				*desc = appendLine(*desc, strings.TrimSpace(line))
+				continue
+			}
+			desc = nil
+			head = append(head, line)
+			continue
+		}
+		args, text := strings.Fields(m[2]), strings.TrimSpace(m[3])
+		desc = nil
+		switch m[1] {
+		case "type":
+			if len(args) > 0 {
+				p := param(args[0])
+				p.Type = text
+			}
+		case "rtype":
+			returns().Type = text
+		case "returns", "return":
+			r := returns()
+			r.Desc = text
+			desc = &r.Desc
+		case "raises", "raise", "except", "exception":
+			r := &symbol.DocParam{Type: strings.Join(args, " "), Desc: text}
+			d.Raises = append(d.Raises, r)
+			desc = &r.Desc
+		default: // :param type name:
+			if len(args) == 0 {
+				continue
+			}
+			p := param(args[len(args)-1])
+			if len(args) > 1 {
+				p.Type = strings.Join(args[:len(args)-1], " ")
+			}
+			p.Desc = text
+			desc = &p.Desc
+		}
+	}
+	return
+}
+
+// `name (type): desc` -> `name (type)`, `desc`, at the first colon out of brackets
+func splitColon(s string) (before, after string) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case ':':
+			if depth == 0 && (i+1 == len(s) || s[i+1] == ' ') {
+				return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:])
+			}
+		}
+	}
+	return strings.TrimSpace(s), ""
+}
+
+func stripBrackets(s string) string {
+	var b strings.Builder
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		default:
+			if depth == 0 {
+				b.WriteByte(s[i])
+			}
+		}
+	}
+	return b.String()
+}
+
+// :func:`numpy.add` -> numpy.add
+func cleanRef(name string) string {
+	name = strings.TrimSpace(name)
+	if strings.HasPrefix(name, ":") {
+		if pos := strings.IndexByte(name, '`'); pos >= 0 {
+			name = name[pos:]
+		}
+	}
+	name = strings.Trim(name, "`~")
+	return strings.TrimSuffix(name, "()")
+}
+
+func joinDesc(first string, lines []string) string {
+	return strings.TrimSpace(appendLine(first, strings.Join(trimBlank(lines), "\n")))
+}
+
+func appendLine(s, line string) string {
+	if s == "" || line == "" {
+		return s + line
+	}
+	return s + "\n" + line
+}
+
+// paragraphs separated by a blank line
+func appendText(s, text string) string {
+	if s == "" || text == "" {
+		return s + text
+	}
+	return s + "\n\n" + text
+}
+
+func indent(lines []string) string {
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		if line != "" {
+			out[i] = "    " + line
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// remove the common indent of lines, and the leading and trailing blank ones
+func dedent(lines []string) []string {
+	lines = trimBlank(lines)
+	min := -1
+	for _, line := range lines {
+		if trimmed := strings.TrimLeft(line, " "); trimmed != "" {
+			if n := len(line) - len(trimmed); min < 0 || n < min {
+				min = n
+			}
+		}
+	}
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		switch {
+		case min <= 0:
+			out[i] = line
+		case len(line) >= min:
+			out[i] = line[min:]
+		default:
+			out[i] = strings.TrimLeft(line, " ") // blank
+		}
+	}
+	return out
+}
+
+func trimBlank(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,173 @@
+package pydoc
+
+import (
+	"encoding/json"
+	"github.com/goplus/llpyg/symbol"
+	"reflect"
+	"testing"
+)
+
+const numpyDoc = `
+    Add arguments element-wise.
+
+    Extended description.
+
+    Parameters
+    ----------
+    x1, x2 : array_like
+        The arrays to be added.
+        If x1.shape != x2.shape, they must be broadcastable.
+    out : ndarray, None, or tuple of ndarray and None, optional
+        A location into which the result is stored.
+    \*\*kwargs
+        For other keyword-only arguments.
+
+    Returns
+    -------
+    add : ndarray or scalar
+        The sum of x1 and x2.
+
+    Raises
+    ------
+    ValueError
+        If the shapes mismatch.
+
+    See Also
+    --------
+    subtract, :func:` + "`numpy.multiply`" + ` : Multiply arguments.
+
+    Notes
+    -----
+    Equivalent to x1 + x2.
+
+    References
+    ----------
+    .. [1] Wikipedia.
+
+    Examples
+    --------
+    >>> np.add(1.0, 4.0)
+    5.0
+    `
+
+func TestNumpydoc(t *testing.T) {
+	d := Parse(numpyDoc)
+	want := &symbol.Docstring{
+		Style:   Numpydoc,
+		Summary: "Add arguments element-wise.",
+		Desc:    "Extended description.\n\nReferences:\n    .. [1] Wikipedia.",
+		Params: []*symbol.DocParam{
+			{Name: "x1", Type: "array_like", Desc: "The arrays to be added.\nIf x1.shape != x2.shape, they must be broadcastable."},
+			{Name: "x2", Type: "array_like", Desc: "The arrays to be added.\nIf x1.shape != x2.shape, they must be broadcastable."},
+			{Name: "out", Type: "ndarray, None, or tuple of ndarray and None, optional", Desc: "A location into which the result is stored."},
+			{Name: "kwargs", Desc: "For other keyword-only arguments."},
+		},
+		Returns:  []*symbol.DocParam{{Name: "add", Type: "ndarray or scalar", Desc: "The sum of x1 and x2."}},
+		Raises:   []*symbol.DocParam{{Type: "ValueError", Desc: "If the shapes mismatch."}},
+		SeeAlso:  []string{"subtract", "numpy.multiply"},
+		Notes:    "Equivalent to x1 + x2.",
+		Examples: ">>> np.add(1.0, 4.0)\n5.0",
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Fatalf("Parse = %s", dump(d))
+	}
+}
+
+func TestGoogle(t *testing.T) {
+	d := Parse(`Fetch rows
+    from a table.
+
+    Args:
+        table (Table): An open table.
+        keys (list[str]): Keys to fetch,
+            in order.
+        *args: Passed on.
+
+    Returns:
+        dict[str, Row]: The rows
+        by key.
+
+    Raises:
+        IOError: An error occurred.
+
+    Example:
+        >>> fetch(t, ["a"])
+    `)
+	want := &symbol.Docstring{
+		Style:   Google,
+		Summary: "Fetch rows from a table.",
+		Params: []*symbol.DocParam{
+			{Name: "table", Type: "Table", Desc: "An open table."},
+			{Name: "keys", Type: "list[str]", Desc: "Keys to fetch,\nin order."},
+			{Name: "args", Desc: "Passed on."},
+		},
+		Returns:  []*symbol.DocParam{{Type: "dict[str, Row]", Desc: "The rows\nby key."}},
+		Raises:   []*symbol.DocParam{{Type: "IOError", Desc: "An error occurred."}},
+		Examples: `>>> fetch(t, ["a"])`,
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Fatalf("Parse = %s", dump(d))
+	}
+	d = Parse("Do it.\n\nReturns:\n    The result, or None.")
+	if len(d.Returns) != 1 || d.Returns[0].Type != "" || d.Returns[0].Desc != "The result, or None." {
+		t.Fatalf("Returns = %s", dump(d.Returns))
+	}
+}
+
+func TestRest(t *testing.T) {
+	d := Parse(`Send a message.
+
+    :param str sender: The person
+        sending the message.
+    :param recipient: The recipient.
+    :type recipient: str
+    :returns: The message id.
+    :rtype: int
+    :raises ValueError: If empty.
+    `)
+	want := &symbol.Docstring{
+		Style:   Rest,
+		Summary: "Send a message.",
+		Params: []*symbol.DocParam{
+			{Name: "sender", Type: "str", Desc: "The person\nsending the message."},
+			{Name: "recipient", Type: "str", Desc: "The recipient."},
+		},
+		Returns: []*symbol.DocParam{{Type: "int", Desc: "The message id."}},
+		Raises:  []*symbol.DocParam{{Type: "ValueError", Desc: "If empty."}},
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Fatalf("Parse = %s", dump(d))
+	}
+}
+
+func TestPlain(t *testing.T) {
+	d := Parse("range(stop) -> range object\n\nReturn an object that produces a sequence.\nExample: range(3)")
+	want := &symbol.Docstring{
+		Summary: "range(stop) -> range object",
+		Desc:    "Return an object that produces a sequence.\nExample: range(3)",
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Fatalf("Parse = %s", dump(d))
+	}
+}
+
+func TestAnnotation(t *testing.T) {
+	cases := map[string]string{
+		"int, optional":           "int",
+		"str, default: 'a'":       "str",
+		"dict[str, int]":          "dict[str, int]",
+		"array_like, optional":    "array_like",
+		"{'C', 'F'}, default 'C'": "{'C', 'F'}",
+		"":                        "",
+	}
+	for in, want := range cases {
+		if got := Annotation(in); got != want {
+			t.Fatalf("Annotation(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func dump(v any) string {
+	data, _ := json.MarshalIndent(v, "", "  ")
+	return string(data)
+}
@@ -0,0 +1,99 @@
+package pygen
+
+import (
+	"strings"
+	"github.com/goplus/llpyg/symbol"
+)
+
+// Links are the go doc links of the symbols bound in a run, by full python
+// name, e.g. numpy.random.Rand -> [example.com/numpy/random.Rand].
+type Links map[string]string
+
+// Add adds the links of the module and its symbols bound in the go package
+// of pkgPath. The first link of a name wins.
+func (l Links) Add(mod *symbol.Module, pkgPath string) {
+	add := func(name, link string) {
+		if _, ok := l[name]; !ok {
+			l[name] = link
+		}
+	}
+	add(mod.Name, "["+pkgPath+"]")
+	boundNames(mod, resolveNames(mod), func(name, goName string) {
+		add(mod.Name+"."+name, "["+pkgPath+"."+goName+"]")
+	})
+}
+
+// calls f with the python and go names of the public symbols of mod that
+// are bound, methods are named like Class.method and Class.Method. The go
+// name of an overload family is its first function.
+func boundNames(mod *symbol.Module, names goNames, f func(name, goName string)) {
+	public := func(name string) bool { return name != "" && name[0] != '_' }
+	for _, sym := range mod.Variables {
+		if public(sym.Name) {
+			f(sym.Name, resolved(names.vars, sym.Name))
+		}
+	}
+	for _, sym := range mod.Functions {
+		if family := boundFamily(sym, false); family != nil {
+			f(sym.Name, overloadName(resolved(names.funcs, sym.Name), 0, len(family)))
+		}
+	}
+	for _, cls := range mod.Classes {
+		if !public(cls.Name) {
+			continue
+		}
+		goName := resolved(names.classes, cls.Name)
+		f(cls.Name, goName)
+		for _, syms := range [][]*symbol.Symbol{cls.Methods, cls.ClassMethods, cls.StaticMethods} {
+			for _, sym := range syms {
+				if family := boundFamily(sym, true); family != nil {
+					f(cls.Name+"."+sym.Name, goName+"."+overloadName(resolved(names.methods[cls.Name], sym.Name), 0, len(family)))
+				}
+			}
+		}
+	}
+}
+
+// go name of a symbol
+func genName(name string) string {
+	return pyToGoName(name, -1)
+}
+
+// go doc links of the symbols of the module, by python name with and
+// without the module name, e.g. numpy.ndarray.sum -> [Ndarray.Sum]
+func (ctx *context) docLinks(mod *symbol.Module) map[string]string {
+	links := make(map[string]string)
+	boundNames(mod, ctx.names, func(name, goName string) {
+		for _, key := range []string{name, mod.Name + "." + name} {
+			if _, ok := links[key]; !ok {
+				links[key] = "[" + goName + "]"
+			}
+		}
+	})
+	return links
+}
+
+// the go doc link of a python name, "" if not bound. Names of the module
+// come first, then the full names of the other modules of the run, then
+// the names relative to the packages of the module, e.g. random.Rand in
+// the docs of numpy or numpy.linalg is numpy.random.Rand.
+func (ctx *context) docLink(name string) string {
+	if link, ok := ctx.links[name]; ok {
+		return link
+	}
+	links := ctx.opts.Links
+	if link, ok := links[name]; ok {
+		return link
+	}
+	for pkg := ctx.name; pkg != ""; {
+		if link, ok := links[pkg+"."+name]; ok {
+			return link
+		}
+		pos := strings.LastIndexByte(pkg, '.')
+		if pos < 0 {
+			break
+		}
+		pkg = pkg[:pos]
+	}
+	return ""
+}
@@ -0,0 +1,215 @@
+package pygen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"github.com/goplus/llpyg/symbol"
+)
+
+// Dumper gets the symbols of a python module.
+type Dumper interface {
+	Dump(moduleName string) (symbol.Module, error)
+}
+
+// DefaultDumper runs the pydump tool found in PATH.
+var DefaultDumper Dumper = &ExecDumper{}
+
+// DumpError reports a module that pydump failed to import,
+// including crashes and timeouts.
+type DumpError struct {
+	Module string
+	Reason string // e.g. "timeout after 120s", "signal: segmentation fault"
+	Stderr string // tail of pydump's stderr
+}
+
+func (e *DumpError) Error() string {
+	return fmt.Sprintf("pydump %s failed: %s", e.Module, e.Reason)
+}
+
+// ExecDumper runs the pydump tool built with LLGo, one process per module.
+type ExecDumper struct {
+	Path    string        // pydump executable, "pydump" if empty
+	Timeout time.Duration // timeout of each module, no timeout if 0
+}
+
+func (d *ExecDumper) Dump(moduleName string) (mod symbol.Module, err error) {
+	path := d.Path
+	if path == "" {
+		path = "pydump"
+	}
+	var out bytes.Buffer
+	stderr := &tailWriter{w: os.Stderr}
+	cmd := exec.Command(path, moduleName)
+	cmd.Stdout = &out
+	cmd.Stderr = stderr
+	if err = cmd.Start(); err != nil {
+		return mod, fmt.Errorf("pydump %s failed: %w", moduleName, err)
+	}
+	timer := killAfter(cmd, d.Timeout)
+	err = cmd.Wait()
+	if err != nil {
+		return mod, newDumpError(moduleName, err, timer, stderr)
+	}
+	timer.Stop()
+	err = json.Unmarshal(out.Bytes(), &mod)
+	if err != nil {
+		return mod, fmt.Errorf("unmarshal %s failed: %w", moduleName, err)
+	}
+	if mod.Name != moduleName {
+		return mod, fmt.Errorf("import module failed: %s", moduleName)
+	}
+	return mod, nil
+}
+
+// killTimer kills a process that runs longer than its timeout.
+type killTimer struct {
+	timer   *time.Timer
+	timeout time.Duration
+	fired   atomic.Bool
+}
+
+func killAfter(cmd *exec.Cmd, timeout time.Duration) *killTimer {
+	t := &killTimer{timeout: timeout}
+	if timeout > 0 {
+		t.timer = time.AfterFunc(timeout, func() {
+			t.fired.Store(true)
+			cmd.Process.Kill()
+		})
+	}
+	return t
+}
+
+func (t *killTimer) Stop() {
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+}
+
+func newDumpError(moduleName string, err error, timer *killTimer, stderr *tailWriter) *DumpError {
+	timer.Stop()
+	reason := err.Error()
+	if timer.fired.Load() {
+		reason = fmt.Sprintf("timeout after %ds", int(timer.timeout.Seconds()))
+	}
+	return &DumpError{Module: moduleName, Reason: reason, Stderr: stderr.Tail()}
+}
+
+// tailWriter passes writes through to w, and keeps the last lines written.
+type tailWriter struct {
+	w     io.Writer
+	mu    sync.Mutex
+	lines []string
+	last  string // incomplete last line
+}
+
+const tailLines = 20
+
+func (t *tailWriter) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	lines := strings.Split(t.last+string(p), "\n")
+	t.last = lines[len(lines)-1]
+	t.lines = append(t.lines, lines[:len(lines)-1]...)
+	if n := len(t.lines); n > tailLines {
+		t.lines = append(t.lines[:0], t.lines[n-tailLines:]...)
+	}
+	if t.w == nil {
+		return len(p), nil
+	}
+	return t.w.Write(p)
+}
+
+// Tail returns the last lines written.
+func (t *tailWriter) Tail() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	lines := t.lines
+	if t.last != "" {
+		lines = append(lines[:len(lines):len(lines)], t.last)
+	}
+	if n := len(lines); n > tailLines {
+		lines = lines[n-tailLines:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Reset forgets the lines written.
+func (t *tailWriter) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lines, t.last = t.lines[:0], ""
+}
+
+// DirDumper reads symbol.Module JSON files saved as Dir/<moduleName>.json.
+type DirDumper struct {
+	Dir string
+}
+
+func (d *DirDumper) Dump(moduleName string) (symbol.Module, error) {
+	return readDump(d.Dir, moduleName)
+}
+
+// CacheDumper dumps each module once with Dumper, and returns the same
+// module, or error, for the later dumps of it.
+type CacheDumper struct {
+	Dumper
+	mods map[string]cachedDump
+}
+
+type cachedDump struct {
+	mod symbol.Module
+	err error
+}
+
+func (d *CacheDumper) Dump(moduleName string) (symbol.Module, error) {
+	if c, ok := d.mods[moduleName]; ok {
+		return c.mod, c.err
+	}
+	mod, err := d.Dumper.Dump(moduleName)
+	if d.mods == nil {
+		d.mods = make(map[string]cachedDump)
+	}
+	d.mods[moduleName] = cachedDump{mod, err}
+	return mod, err
+}
+
+// DumpModules returns the names of the modules saved in dumpDir, in sorted order.
+func DumpModules(dumpDir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dumpDir, "*"+dumpExt))
+	if err != nil {
+		return nil, err
+	}
+	modules := make([]string, 0, len(files))
+	for _, file := range files {
+		modules = append(modules, strings.TrimSuffix(filepath.Base(file), dumpExt))
+	}
+	sort.Strings(modules)
+	return modules, nil
+}
+
+const dumpExt = ".json"
+
+func readDump(dumpDir, moduleName string) (mod symbol.Module, err error) {
+	data, err := os.ReadFile(filepath.Join(dumpDir, moduleName+dumpExt))
+	if err != nil {
+		return mod, fmt.Errorf("read dump of %s failed: %w", moduleName, err)
+	}
+	err = json.Unmarshal(data, &mod)
+	if err != nil {
+		return mod, fmt.Errorf("unmarshal %s failed: %w", moduleName, err)
+	}
+	if mod.Name != moduleName {
+		return mod, fmt.Errorf("dump of %s has module name %s", moduleName, mod.Name)
+	}
+	return mod, nil
+}
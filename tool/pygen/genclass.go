@@ -0,0 +1,134 @@
+package pygen
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+	"github.com/goplus/gogen"
+	"github.com/goplus/llpyg/symbol"
+	"github.com/goplus/llpyg/tool/pysig"
+)
+
+// class Foo -> type Foo struct{ py.Object }
+func (ctx *context) genClass(pkg *gogen.Package, cls *symbol.Class) {
+	name := cls.Name
+	if len(name) == 0 || name[0] == '_' {
+		ctx.skip(name, ReasonPrivate)
+		return
+	}
+	goName := resolved(ctx.names.classes, name)
+	fields := []*types.Var{
+		types.NewField(token.NoPos, pkg.Types, "Object", ctx.obj, true), // embedded py.Object
+	}
+	defs := pkg.NewTypeDefs()
+	if docList := ctx.genDoc(cls.Doc); len(docList) > 0 {
+		defs.SetComments(&ast.CommentGroup{List: docList})
+	}
+	named := defs.NewType(goName).InitType(pkg, types.NewStruct(fields, nil))
+	recv := types.NewPointer(named)
+	ctx.generated(name, goName)
+	for _, syms := range [][]*symbol.Symbol{cls.Methods, cls.ClassMethods, cls.StaticMethods} {
+		for _, sym := range syms {
+			ctx.genMethod(pkg, recv, cls, sym)
+		}
+	}
+}
+
+// method Foo.bar -> func (f *Foo) Bar() *py.Object
+func (ctx *context) genMethod(pkg *gogen.Package, recv *types.Pointer, cls *symbol.Class, sym *symbol.Symbol) {
+	name, symSig := sym.Name, sym.Sig
+	qualified := cls.Name + "." + name
+	if len(name) == 0 || name[0] == '_' {
+		ctx.skip(qualified, ReasonPrivate)
+		return
+	}
+	if symSig == "" { // no signature
+		ctx.skip(qualified, ReasonNoSignature)
+		return
+	}
+	if err := sigError(sym); err != nil {
+		ctx.skip(qualified, ReasonUnparsable+": "+err.Error())
+		return
+	}
+	args := dropSelf(symbolArgs(sym)) // self is the receiver
+	family := overloadFamily(sym, args, true)
+	if reason := paramProblem(family); reason != "" {
+		ctx.skip(qualified, reason)
+		return
+	}
+	_, _, kwargs := ctx.genParams(pkg, args)
+	typeName := recv.Elem().(*types.Named).Obj().Name()
+	goName := resolved(ctx.names.methods[cls.Name], name)
+	kwOnly := requiredKeywords(args) != nil // only callable with keyword arguments
+	bindings := family
+	if kwOnly {
+		bindings = nil
+	}
+	for i, forms := range bindings {
+		fnName := overloadName(goName, i, len(bindings))
+		fnParams, fnVariadic, _ := ctx.genParams(pkg, forms)
+		recvParam := pkg.NewParam(token.NoPos, ctx.genRecvName(typeName, fnParams), recv)
+		sig := types.NewSignatureType(recvParam, nil, nil, fnParams, ctx.ret, fnVariadic) // ret: *py.Object
+		fn, err := pkg.NewFuncWith(token.NoPos, fnName, sig, nil)
+		if err != nil {
+			ctx.fail(qualified, err)
+			return
+		}
+		fn.BodyStart(pkg).Val(nil).Return(1).End() // { return nil }
+		// doc
+		docList := ctx.genOverloadDoc(sym, goName, bindings, i)
+		docList = append(docList, ctx.genLink(typeName, fnName, cls, sym))
+		fn.SetComments(pkg, &ast.CommentGroup{List: docList})
+	}
+	ctx.generated(qualified, goName)
+	if kwargs {
+		ctx.genMethodKw(pkg, recv, cls, sym, goName, family, kwOnly)
+	}
+}
+
+// func (f *Foo) BarKw(a *py.Object, kw *py.Object) *py.Object {
+//	return f.Object.GetAttr(py.Str("bar")).Call(py.Tuple(a), kw)
+// }
+//
+// one for each call form, see genFuncKw
+func (ctx *context) genMethodKw(pkg *gogen.Package, recv *types.Pointer, cls *symbol.Class, sym *symbol.Symbol, goName string, family [][]*pysig.Arg, kwOnly bool) {
+	typeName := recv.Elem().(*types.Named).Obj().Name()
+	for i, form := range family {
+		params, kw, vargs, list := ctx.genKwParams(pkg, form)
+		recvParam := pkg.NewParam(token.NoPos, ctx.genRecvName(typeName, types.NewTuple(list...)), recv)
+		sig := types.NewSignatureType(recvParam, nil, nil, types.NewTuple(list...), ctx.ret, vargs != nil)
+		fn, err := pkg.NewFuncWith(token.NoPos, overloadName(goName+"Kw", i, len(family)), sig, nil)
+		if err != nil {
+			ctx.fail(cls.Name+"."+sym.Name, err)
+			return
+		}
+		cb := fn.BodyStart(pkg)
+		tuple := ctx.genKwArgs(cb, params, vargs, append(list, recvParam))
+		cb.Val(recvParam).MemberVal("Object").MemberVal("GetAttr")
+		cb.Val(ctx.py.Ref("Str")).Val(sym.Name).Call(1).Call(1)
+		ctx.genKwCall(cb, tuple, kw).Return(1).End()
+		fn.SetComments(pkg, ctx.genKwDoc(sym, goName, family, i, kwOnly, kw, vargs))
+	}
+}
+
+func (ctx *context) genLink(typeName, name string, cls *symbol.Class, sym *symbol.Symbol) *ast.Comment {
+	return &ast.Comment{Text: "//llgo:link (*" + typeName + ")." + name + " py." + cls.Name + "." + sym.Name}
+}
+
+// lower case initial of the type name, must not shadow a parameter
+func (ctx *context) genRecvName(typeName string, params *types.Tuple) string {
+	list := make([]*types.Var, params.Len())
+	for i := range list {
+		list[i] = params.At(i)
+	}
+	return uniqueParamName(strings.ToLower(typeName[:1]), list)
+}
+
+// args without the first one if it is self
+func dropSelf(args []*pysig.Arg) []*pysig.Arg {
+	if len(args) > 0 && strings.TrimSpace(args[0].Name) == "self" {
+		return args[1:]
+	}
+	return args
+}
@@ -4,33 +4,210 @@ import (
 	"go/ast"
 	"go/token"
 	"go/types"
+	"strings"
 	"github.com/goplus/gogen"
 	"github.com/goplus/llpyg/symbol"
+	"github.com/goplus/llpyg/tool/pysig"
 )
 
 func (ctx *context) genFunc(pkg *gogen.Package, sym *symbol.Symbol) {
 	name, symSig := sym.Name, sym.Sig
 	if len(name) == 0 || name[0] == '_' {
+		ctx.skip(name, ReasonPrivate)
 		return
 	}
 	if symSig == "" { // no signature
-		ctx.skips = append(ctx.skips, *sym)
+		ctx.skip(name, ReasonNoSignature)
+		return
+	}
+	if err := sigError(sym); err != nil {
+		ctx.skip(name, ReasonUnparsable+": "+err.Error())
 		return
 	}
 	// signature
-	params, variadic := ctx.genParams(pkg, symSig)
-	goName := ctx.genName(name, -1)
-	sig := types.NewSignatureType(nil, nil, nil, params, ctx.ret, variadic) // ret: *py.Object
-	fn := pkg.NewFuncDecl(token.NoPos, goName, sig)
-	// doc
-	docList := ctx.genDoc(sym.Doc)
-	if len(docList) > 0 {
-		docList = append(docList, emptyCommentLine)
+	args := symbolArgs(sym)
+	family := overloadFamily(sym, args, false)
+	if reason := paramProblem(family); reason != "" {
+		ctx.skip(name, reason)
+		return
+	}
+	_, variadic, kwargs := ctx.genParams(pkg, args)
+	goName := resolved(ctx.names.funcs, name)
+	rawName := goName
+	kwOnly := requiredKeywords(args) != nil // only callable with keyword arguments
+	bindings := family
+	if kwOnly {
+		bindings, rawName = nil, ""
+	}
+	for i, forms := range bindings {
+		fnName := overloadName(goName, i, len(bindings))
+		fnParams, fnVariadic, _ := ctx.genParams(pkg, forms)
+		sig := types.NewSignatureType(nil, nil, nil, fnParams, ctx.ret, fnVariadic) // ret: *py.Object
+		fn := pkg.NewFuncDecl(token.NoPos, fnName, sig)
+		// doc
+		docList := ctx.genOverloadDoc(sym, goName, bindings, i)
+		docList = append(docList, ctx.genLinkname(fnName, sym))
+		fn.SetComments(pkg, &ast.CommentGroup{List: docList})
+		if sameArgs(forms, args) {
+			rawName = fnName
+		}
+	}
+	ctx.generated(name, goName)
+	if kwargs {
+		ctx.genFuncKw(pkg, sym, goName, family, kwOnly)
+	}
+	if ctx.opts.Typed && !variadic && rawName != "" {
+		ctx.genFuncTyped(pkg, sym, goName, rawName)
 	}
-	docList = append(docList, ctx.genLinkname(goName, sym))
-	fn.SetComments(pkg, &ast.CommentGroup{List: docList})
 }
 
 func (ctx *context) genLinkname(name string, sym *symbol.Symbol) *ast.Comment {
 	return &ast.Comment{Text: "//go:linkname " + name + " py." + sym.Name}
 }
+
+// LLGo passes positional arguments only, so keyword arguments are reached
+// by calling the python function object:
+//
+//	//go:linkname pyFoo py.foo
+//	var pyFoo *py.Object
+//
+//	func FooKw(a *py.Object, kw *py.Object) *py.Object {
+//		return pyFoo.Call(py.Tuple(a), kw)
+//	}
+//
+// The Kw variant of a function with *args also takes them, see genKwArgs.
+// Each call form of an overload family gets one, like FooKw__0(a, kw) and
+// FooKw__1(a, b, kw) of Foo__0(a) and Foo__1(a, b), so the optional
+// positional parameters can be passed as keywords. kwOnly tells the
+// symbol has keyword-only parameters without default, and no bindings.
+func (ctx *context) genFuncKw(pkg *gogen.Package, sym *symbol.Symbol, goName string, family [][]*pysig.Arg, kwOnly bool) {
+	varName := "py" + goName
+	pkg.NewVarDefs(pkg.Types.Scope()).SetComments(&ast.CommentGroup{
+		List: []*ast.Comment{ctx.genLinkname(varName, sym)},
+	}).New(token.NoPos, ctx.objPtr, varName)
+	for i, form := range family {
+		params, kw, vargs, list := ctx.genKwParams(pkg, form)
+		sig := types.NewSignatureType(nil, nil, nil, types.NewTuple(list...), ctx.ret, vargs != nil)
+		fn, err := pkg.NewFuncWith(token.NoPos, overloadName(goName+"Kw", i, len(family)), sig, nil)
+		if err != nil {
+			ctx.fail(sym.Name, err)
+			return
+		}
+		cb := fn.BodyStart(pkg)
+		tuple := ctx.genKwArgs(cb, params, vargs, list)
+		cb.Val(pkg.Types.Scope().Lookup(varName))
+		ctx.genKwCall(cb, tuple, kw).Return(1).End()
+		fn.SetComments(pkg, ctx.genKwDoc(sym, goName, family, i, kwOnly, kw, vargs))
+	}
+}
+
+// the positional parameters of a call form, the kw parameter following
+// them, and the *args one after it, nil if none, with all the parameters
+func (ctx *context) genKwParams(pkg *gogen.Package, form []*pysig.Arg) (params []*types.Var, kw, vargs *types.Var, list []*types.Var) {
+	tuple, variadic, _ := ctx.genParams(pkg, form)
+	params = positionalParams(tuple, variadic)
+	list = append(list, params...)
+	kw = pkg.NewParam(token.NoPos, uniqueParamName("kw", list), ctx.objPtr)
+	list = append(list, kw)
+	if variadic {
+		vargs = pkg.NewParam(token.NoPos, uniqueParamName("args", list), types.NewSlice(ctx.objPtr))
+		list = append(list, vargs)
+	}
+	return
+}
+
+// returns a func pushing the tuple of the positional arguments. With
+// *args, they are appended to a list before:
+//
+//	list := py.NewList(0)
+//	list.ListAppend(a)
+//	for _, arg := range args {
+//		list.ListAppend(arg)
+//	}
+//	... list.ListAsTuple()
+func (ctx *context) genKwArgs(cb *gogen.CodeBuilder, params []*types.Var, vargs *types.Var, all []*types.Var) func() {
+	if vargs == nil {
+		return func() {
+			cb.Val(ctx.py.Ref("Tuple"))
+			for _, param := range params {
+				cb.Val(param)
+			}
+			cb.Call(len(params))
+		}
+	}
+	name := uniqueParamName("list", all)
+	cb.DefineVarStart(token.NoPos, name).Val(ctx.py.Ref("NewList")).Val(0).Call(1).EndInit(1)
+	list := cb.Scope().Lookup(name).(*types.Var)
+	for _, param := range params {
+		cb.Val(list).MemberVal("ListAppend").Val(param).Call(1).EndStmt()
+	}
+	arg := uniqueParamName("arg", append(all, list))
+	cb.ForRange("_", arg).Val(vargs).RangeAssignThen(token.NoPos)
+	cb.Val(list).MemberVal("ListAppend").Val(cb.Scope().Lookup(arg)).Call(1).EndStmt().End()
+	return func() { cb.Val(list).MemberVal("ListAsTuple").Call(0) }
+}
+
+// push .Call(tuple, kw) on the callable at the top of the stack
+func (ctx *context) genKwCall(cb *gogen.CodeBuilder, tuple func(), kw *types.Var) *gogen.CodeBuilder {
+	cb.MemberVal("Call")
+	tuple()
+	return cb.Val(kw).Call(2)
+}
+
+// the Kw variant of the i-th form is like the binding of the form. With
+// kwOnly, there are no bindings, and the first Kw variant has the doc of
+// the symbol.
+func (ctx *context) genKwDoc(sym *symbol.Symbol, goName string, family [][]*pysig.Arg, i int, kwOnly bool, kw, vargs *types.Var) *ast.CommentGroup {
+	fnName := overloadName(goName+"Kw", i, len(family))
+	var list []*ast.Comment
+	switch {
+	case !kwOnly:
+		list = []*ast.Comment{
+			{Text: "// " + fnName + " is like " + overloadName(goName, i, len(family)) + " but also accepts keyword arguments,"},
+			{Text: "// " + kw.Name() + " is a dict mapping argument names to values."},
+		}
+	case i == 0:
+		list = append(ctx.genSymDoc(sym),
+			&ast.Comment{Text: "// " + fnName + " calls " + sym.Name + " with keyword arguments, " + kw.Name() + " is a dict"},
+			&ast.Comment{Text: "// mapping argument names to values, it must hold " + strings.Join(requiredKeywords(symbolArgs(sym)), ", ") + "."},
+		)
+	default:
+		list = ctx.genOverloadDoc(sym, goName+"Kw", family, i)[:1] // without the empty line
+	}
+	if vargs != nil {
+		list = append(list, &ast.Comment{Text: "// The " + vargs.Name() + " are passed to *args after the other positional arguments."})
+	}
+	return &ast.CommentGroup{List: list}
+}
+
+// names of the keyword-only parameters without default, nil if none
+func requiredKeywords(args []*pysig.Arg) (names []string) {
+	for _, arg := range args {
+		if arg.Kind == pysig.KeywordOnly && arg.DefVal == "" && !arg.Optional {
+			names = append(names, arg.Name)
+		}
+	}
+	return
+}
+
+// parameters without the trailing *args
+func positionalParams(params *types.Tuple, variadic bool) []*types.Var {
+	n := params.Len()
+	if variadic {
+		n--
+	}
+	list := make([]*types.Var, 0, n+1)
+	for i := 0; i < n; i++ {
+		list = append(list, params.At(i))
+	}
+	return list
+}
+
+func uniqueParamName(name string, params []*types.Var) string {
+	for _, param := range params {
+		if param.Name() == name {
+			return uniqueParamName(name+"_", params)
+		}
+	}
+	return name
+}
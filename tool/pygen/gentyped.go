@@ -0,0 +1,452 @@
+package pygen
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+	"github.com/goplus/gogen"
+	"github.com/goplus/llpyg/symbol"
+	"github.com/goplus/llpyg/tool/pysig"
+)
+
+// bits of the type flags of python objects that are instances of a builtin type
+const (
+	pyLongSubclass    = 24
+	pyListSubclass    = 25
+	pyUnicodeSubclass = 28
+)
+
+// a go type of typed wrappers and its conversions from and to python
+type typedConv struct {
+	name   string     // python type, e.g. list[int]
+	typ    types.Type // go type, e.g. []int64
+	elem   *typedConv // of list items
+	toPy   string     // py or C API function of a go value, "" if not an argument type
+	fromPy string     // py.Object method of the go value, e.g. LongLong
+	flag   int        // bit of the type flag a result must have, 0 for no check
+	local  string     // variable of a converted result that is checked, see fromPy
+}
+
+// the results of these convs are checked too, see typedWriter.check
+func (conv *typedConv) checked() bool {
+	return conv.flag != 0 || conv == typedFloat
+}
+
+var (
+	typedInt   = &typedConv{name: "int", typ: types.Typ[types.Int64], toPy: "LongLong", fromPy: "LongLong", flag: pyLongSubclass, local: "n"}
+	typedFloat = &typedConv{name: "float", typ: types.Typ[types.Float64], toPy: "Float", fromPy: "Float64", local: "f"}
+	typedStr   = &typedConv{name: "str", typ: types.Typ[types.String], toPy: "Str", fromPy: "CStr", flag: pyUnicodeSubclass}
+	typedBool  = &typedConv{name: "bool", typ: types.Typ[types.Bool], toPy: cBoolFromLong, fromPy: "IsTrue", local: "b"}
+	typedNone  = &typedConv{name: "None"}
+)
+
+// the conversion of a simple annotation, nil if it has none. Lists of
+// scalars are converted item by item. Bytes are out of scope, as
+// github.com/goplus/lib/py has no bytes API, so functions with bytes
+// parameters get no typed wrapper, and bytes results are *py.Object.
+func typedConvOf(annotation string) *typedConv {
+	if annotation == "" {
+		return nil
+	}
+	t, err := pysig.ParseType(annotation)
+	if err != nil {
+		return nil
+	}
+	return typedConvOfType(t)
+}
+
+func typedConvOfType(t pysig.Type) *typedConv {
+	switch t := t.(type) {
+	case *pysig.ForwardRef:
+		return typedConvOfType(t.Elem)
+	case *pysig.NameType:
+		switch t.Name {
+		case "int", "builtins.int":
+			return typedInt
+		case "float", "builtins.float":
+			return typedFloat
+		case "str", "builtins.str":
+			return typedStr
+		case "bool", "builtins.bool":
+			return typedBool
+		case "None":
+			return typedNone
+		}
+	case *pysig.GenericType:
+		base, ok := t.Base.(*pysig.NameType)
+		if !ok || len(t.Args) != 1 {
+			return nil
+		}
+		switch base.Name {
+		case "list", "List", "typing.List", "builtins.list":
+			elem := typedConvOfType(t.Args[0])
+			if elem == nil || elem == typedNone {
+				return nil
+			}
+			return &typedConv{name: "list[" + elem.name + "]", typ: types.NewSlice(elem.typ), elem: elem, flag: pyListSubclass}
+		}
+	}
+	return nil
+}
+
+// a function with simple annotations, like func_c(a: int, b: float) -> str,
+// also gets a wrapper with go types:
+//
+//	func FuncCTyped(a int64, b float64) (string, error) {
+//		ret := FuncC(py.LongLong(a), py.Float(b))
+//		if ret == nil {
+//			py.ErrClear()
+//			return "", errors.New("demo.func_c raised an exception")
+//		}
+//		typ := ret.Type()
+//		ok := typ.TypeFlags()&(1<<28) != 0
+//		typ.DecRef()
+//		if !ok {
+//			return "", errors.New("demo.func_c returned a value that is not a str")
+//		}
+//		return c.GoString(ret.CStr()), nil
+//	}
+//
+// All the positional parameters need an annotation with a conversion,
+// the result is *py.Object if its annotation has none. rawName is the
+// binding passing all of them.
+func (ctx *context) genFuncTyped(pkg *gogen.Package, sym *symbol.Symbol, goName, rawName string) {
+	var convs []*typedConv
+	var params []*types.Var
+	for _, arg := range symbolArgs(sym) {
+		switch arg.Kind {
+		case pysig.VarPositional:
+			return
+		case pysig.KeywordOnly, pysig.VarKeyword:
+			continue
+		}
+		conv := typedConvOf(arg.Type)
+		if conv == nil || !conv.isArg() {
+			return
+		}
+		name := arg.Name
+		if goKeywords[name] {
+			name += "_"
+		}
+		convs = append(convs, conv)
+		params = append(params, pkg.NewParam(token.NoPos, name, conv.typ))
+	}
+	ret := typedConvOf(symbolReturn(sym))
+	if ret == nil && len(convs) == 0 {
+		return // nothing to convert
+	}
+	results := []*types.Var{pkg.NewParam(token.NoPos, "", ctx.objPtr)}
+	switch ret {
+	case nil:
+	case typedNone:
+		results = nil
+	default:
+		results = []*types.Var{pkg.NewParam(token.NoPos, "", ret.typ)}
+	}
+	errType := types.Universe.Lookup("error").Type()
+	results = append(results, pkg.NewParam(token.NoPos, "", errType))
+	sig := types.NewSignatureType(nil, nil, nil, types.NewTuple(params...), types.NewTuple(results...), false)
+	fn, err := pkg.NewFuncWith(token.NoPos, goName+"Typed", sig, nil)
+	if err != nil {
+		ctx.fail(sym.Name, err)
+		return
+	}
+	w := &typedWriter{ctx: ctx, cb: fn.BodyStart(pkg), pyName: ctx.pyName(sym.Name), params: params, results: results}
+	// arguments
+	args := make([]func(), len(params))
+	for i, param := range params {
+		args[i] = w.toPy(convs[i], param)
+	}
+	// call
+	w.result = w.newVar("ret")
+	w.cb.DefineVarStart(token.NoPos, w.result.Name()).Val(pkg.Types.Scope().Lookup(rawName))
+	for _, push := range args {
+		push()
+	}
+	w.cb.Call(len(args)).EndInit(1)
+	w.result = w.cb.Scope().Lookup(w.result.Name()).(*types.Var)
+	w.cb.If().Val(w.result).CompareNil(token.EQL).Then()
+	w.cb.Val(ctx.py.Ref("ErrClear")).Call(0).EndStmt()
+	w.returnErr("raised an exception")
+	w.cb.End()
+	// result
+	switch ret {
+	case nil:
+		w.cb.Val(w.result)
+	case typedNone:
+	default:
+		w.check(ret, w.result, "returned a value that is not")
+		w.fromPy(ret, w.result)()
+	}
+	w.cb.Val(nil).Return(len(results)).End()
+	fn.SetComments(pkg, ctx.genTypedDoc(goName, rawName, sym.Name, ret))
+}
+
+func (conv *typedConv) isArg() bool {
+	if conv.elem != nil {
+		return conv.elem.isArg()
+	}
+	return conv.toPy != ""
+}
+
+// the full python name of a symbol of the module
+func (ctx *context) pyName(name string) string {
+	if ctx.name == "" {
+		return name
+	}
+	return ctx.name + "." + name
+}
+
+func (ctx *context) genTypedDoc(goName, rawName, name string, ret *typedConv) *ast.CommentGroup {
+	list := []*ast.Comment{
+		{Text: "// " + goName + "Typed is like " + rawName + " but with go types, it converts the arguments"},
+		{Text: "// and the result, and returns an error if " + name + " raises an exception"},
+	}
+	if ret != nil && ret.checked() {
+		list[1].Text += ","
+		list = append(list, &ast.Comment{Text: "// or returns a value that is not " + article(ret.name) + " " + ret.name + "."})
+	} else {
+		list[1].Text += "."
+	}
+	return &ast.CommentGroup{List: list}
+}
+
+type typedWriter struct {
+	ctx     *context
+	cb      *gogen.CodeBuilder
+	pyName  string
+	params  []*types.Var
+	results []*types.Var
+	result  *types.Var
+	names   []string // of the local variables
+}
+
+// a local variable name not used by the parameters and other variables
+func (w *typedWriter) newVar(name string) *types.Var {
+	for w.used(name) {
+		name += "_"
+	}
+	w.names = append(w.names, name)
+	return types.NewVar(token.NoPos, nil, name, nil)
+}
+
+func (w *typedWriter) used(name string) bool {
+	for _, param := range w.params {
+		if param.Name() == name {
+			return true
+		}
+	}
+	for _, used := range w.names {
+		if used == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *typedWriter) lookup(v *types.Var) *types.Var {
+	return w.cb.Scope().Lookup(v.Name()).(*types.Var)
+}
+
+// define a local variable initialized by the value init pushes
+func (w *typedWriter) define(name string, init func()) *types.Var {
+	v := w.newVar(name)
+	w.cb.DefineVarStart(token.NoPos, v.Name())
+	init()
+	w.cb.EndInit(1)
+	return w.lookup(v)
+}
+
+// returns a func pushing the python object of a go parameter, lists are
+// built item by item before the call:
+//
+//	a_ := py.NewList(len(a))
+//	for i, item := range a {
+//		a_.ListSetItem(i, py.LongLong(item))
+//	}
+//
+// and bools are passed to PyBool_FromLong as a c.Long:
+//
+//	var on_ c.Long
+//	if on {
+//		on_ = 1
+//	}
+//	... pyBool_FromLong(on_)
+func (w *typedWriter) toPy(conv *typedConv, param *types.Var) func() {
+	switch {
+	case conv.elem != nil:
+		builtin := w.ctx.pkg.Builtin()
+		list := w.define(param.Name()+"_", func() {
+			w.cb.Val(w.ctx.py.Ref("NewList")).Val(builtin.Ref("len")).Val(param).Call(1).Call(1)
+		})
+		i, item := w.newVar("i"), w.newVar("item")
+		w.cb.ForRange(i.Name(), item.Name()).Val(param).RangeAssignThen(token.NoPos)
+		push := w.toPy(conv.elem, w.lookup(item))
+		w.cb.Val(list).MemberVal("ListSetItem").Val(w.lookup(i))
+		push()
+		w.cb.Call(2).EndStmt().End()
+		return func() { w.cb.Val(list) }
+	case conv == typedBool:
+		c := w.ctx.pkg.Import("github.com/goplus/lib/c")
+		long := w.newVar(param.Name() + "_")
+		w.cb.NewVar(c.Ref("Long").Type(), long.Name())
+		long = w.lookup(long)
+		w.cb.If().Val(param).Then().VarRef(long).Val(1).Assign(1).End()
+		return func() { w.cb.Val(w.ctx.cAPI(conv.toPy)).Val(long).Call(1) }
+	default:
+		return func() { w.cb.Val(w.ctx.py.Ref(conv.toPy)).Val(param).Call(1) }
+	}
+}
+
+// return the zero results and errors.New(python name + " " + msg)
+func (w *typedWriter) returnErr(msg string) {
+	for _, result := range w.results[:len(w.results)-1] {
+		w.cb.ZeroLit(result.Type())
+	}
+	errors := w.ctx.pkg.Import("errors")
+	w.cb.Val(errors.Ref("New")).Val(w.pyName + " " + msg).Call(1).Return(len(w.results))
+}
+
+// return an error if the type of v lacks the flag of conv, the type is a
+// new reference:
+//
+//	typ := v.Type()
+//	ok := typ.TypeFlags()&(1<<flag) != 0
+//	typ.DecRef()
+//	if !ok {
+//		return ..., errors.New(...)
+//	}
+//
+// There is no flag of floats, a float is a value of type float or an int:
+//
+//	zero := py.Float(0)
+//	floatType := zero.Type()
+//	ok := typ == floatType || typ.TypeFlags()&(1<<24) != 0
+func (w *typedWriter) check(conv *typedConv, v *types.Var, msg string) {
+	if !conv.checked() {
+		return
+	}
+	typ := w.define("typ", func() { w.cb.Val(v).MemberVal("Type").Call(0) })
+	hasFlag := func(flag int) {
+		w.cb.Val(typ).MemberVal("TypeFlags").Call(0).
+			Val(1).Val(flag).BinaryOp(token.SHL).BinaryOp(token.AND).Val(0).BinaryOp(token.NEQ)
+	}
+	refs := []*types.Var{typ}
+	var ok *types.Var
+	if conv == typedFloat {
+		zero := w.define("zero", func() { w.cb.Val(w.ctx.py.Ref("Float")).Val(0).Call(1) })
+		floatType := w.define("floatType", func() { w.cb.Val(zero).MemberVal("Type").Call(0) })
+		ok = w.define("ok", func() {
+			w.cb.Val(typ).Val(floatType).BinaryOp(token.EQL)
+			hasFlag(pyLongSubclass)
+			w.cb.BinaryOp(token.LOR)
+		})
+		refs = append(refs, floatType, zero)
+	} else {
+		ok = w.define("ok", func() { hasFlag(conv.flag) })
+	}
+	for _, ref := range refs {
+		w.cb.Val(ref).MemberVal("DecRef").Call(0).EndStmt()
+	}
+	w.cb.If().Val(ok).UnaryOp(token.NOT).Then()
+	w.returnErr(msg + " " + article(conv.name) + " " + conv.name)
+	w.cb.End()
+}
+
+// returns a func pushing the go value of a python object. The items of
+// lists are checked and converted one by one before:
+//
+//	list := make([]int64, ret.ListLen())
+//	for i := range list {
+//		item := ret.ListItem(i)
+//		...check and convert item
+//		list[i] = n
+//	}
+//
+// and so are scalars but strs, LongLong and Float64 return -1 with an
+// exception set on errors, IsTrue returns -1 on errors:
+//
+//	n := ret.LongLong()
+//	if n == -1 && pyErr_Occurred() != nil {
+//		py.ErrClear()
+//		return 0, errors.New("demo.f raised an exception converting the result to an int")
+//	}
+//	... n
+//
+// The truth value of a bool is pushed as b != 0.
+func (w *typedWriter) fromPy(conv *typedConv, v *types.Var) func() {
+	switch {
+	case conv.elem != nil:
+		builtin := w.ctx.pkg.Builtin()
+		list := w.newVar("list")
+		w.cb.DefineVarStart(token.NoPos, list.Name()).Val(builtin.Ref("make")).Typ(conv.typ).
+			Val(v).MemberVal("ListLen").Call(0).Call(2).EndInit(1)
+		list = w.lookup(list)
+		i := w.newVar("i")
+		w.cb.ForRange(i.Name()).Val(list).RangeAssignThen(token.NoPos)
+		item := w.define("item", func() { w.cb.Val(v).MemberVal("ListItem").Val(w.lookup(i)).Call(1) })
+		w.check(conv.elem, item, "returned a list with an item that is not")
+		push := w.fromPy(conv.elem, item)
+		w.cb.Val(list).Val(w.lookup(i)).IndexRef(1)
+		push()
+		w.cb.Assign(1).End()
+		return func() { w.cb.Val(list) }
+	case conv == typedStr:
+		c := w.ctx.pkg.Import("github.com/goplus/lib/c")
+		return func() { w.cb.Val(c.Ref("GoString")).Val(v).MemberVal(conv.fromPy).Call(0).Call(1) }
+	case conv.local != "":
+		x := w.define(conv.local, func() { w.cb.Val(v).MemberVal(conv.fromPy).Call(0) })
+		w.cb.If().Val(x).Val(-1).BinaryOp(token.EQL)
+		if conv != typedBool {
+			w.cb.Val(w.ctx.cAPI(cErrOccurred)).Call(0).CompareNil(token.NEQ).BinaryOp(token.LAND)
+		}
+		w.cb.Then()
+		w.cb.Val(w.ctx.py.Ref("ErrClear")).Call(0).EndStmt()
+		w.returnErr("raised an exception converting the result to " + article(conv.name) + " " + conv.name)
+		w.cb.End()
+		if conv == typedBool {
+			return func() { w.cb.Val(x).Val(0).BinaryOp(token.NEQ) }
+		}
+		return func() { w.cb.Val(x) }
+	default:
+		return func() { w.cb.Val(v).MemberVal(conv.fromPy).Call(0) }
+	}
+}
+
+func article(name string) string {
+	if strings.IndexByte("aeiou", name[0]) >= 0 {
+		return "an"
+	}
+	return "a"
+}
+
+// C API functions that github.com/goplus/lib/py lacks, linked in the
+// package by the first typed wrapper using them. The underscore keeps
+// them apart from the generated names.
+const (
+	cBoolFromLong = "pyBool_FromLong"
+	cErrOccurred  = "pyErr_Occurred"
+)
+
+// the C API function of name, declared at its first use:
+//
+//	//go:linkname pyBool_FromLong C.PyBool_FromLong
+//	func pyBool_FromLong(v c.Long) *py.Object
+func (ctx *context) cAPI(name string) types.Object {
+	scope := ctx.pkg.Types.Scope()
+	if obj := scope.Lookup(name); obj != nil {
+		return obj
+	}
+	var params []*types.Var
+	if name == cBoolFromLong {
+		c := ctx.pkg.Import("github.com/goplus/lib/c")
+		params = append(params, ctx.pkg.NewParam(token.NoPos, "v", c.Ref("Long").Type()))
+	}
+	sig := types.NewSignatureType(nil, nil, nil, types.NewTuple(params...), ctx.ret, false)
+	fn := ctx.pkg.NewFuncDecl(token.NoPos, name, sig)
+	fn.SetComments(ctx.pkg, &ast.CommentGroup{List: []*ast.Comment{
+		{Text: "//go:linkname " + name + " C.P" + name[1:]},
+	}})
+	return scope.Lookup(name)
+}
@@ -0,0 +1,26 @@
+package pygen
+
+import (
+	"go/ast"
+	"go/token"
+	"github.com/goplus/gogen"
+	"github.com/goplus/llpyg/symbol"
+)
+
+// variable pi -> var Pi *py.Object
+func (ctx *context) genVar(pkg *gogen.Package, sym *symbol.Symbol) {
+	name := sym.Name
+	if len(name) == 0 || name[0] == '_' {
+		ctx.skip(name, ReasonPrivate)
+		return
+	}
+	goName := resolved(ctx.names.vars, name)
+	docList := make([]*ast.Comment, 0, 3)
+	if sym.Repr != "" {
+		docList = append(docList, &ast.Comment{Text: "// " + name + " = " + sym.Repr}, emptyCommentLine)
+	}
+	docList = append(docList, ctx.genLinkname(goName, sym))
+	defs := pkg.NewVarDefs(pkg.Types.Scope()).SetComments(&ast.CommentGroup{List: docList})
+	defs.New(token.NoPos, ctx.objPtr, goName)
+	ctx.generated(name, goName)
+}
@@ -0,0 +1,159 @@
+package pygen
+
+import (
+	"strconv"
+	"github.com/goplus/llpyg/symbol"
+	"github.com/goplus/llpyg/tool/pysig"
+)
+
+// Python names that only differ in case or underscores, like sum and Sum
+// or func_a and funcA, have the same go name. The first symbol of a go
+// name keeps it, in the order of generation: variables, functions, then
+// classes, each in the order of the dump. The next ones get the first
+// free name of Name_2, Name_3 and so on, which genName never returns as
+// it drops the underscores inside names.
+//
+// A function also takes NameKw and NameTyped, whether it gets such a
+// variant or not, so the names don't depend on the options. LLGoPackage
+// and GopPackage are reserved. Methods are named the same way in their
+// class, where Object is the embedded py.Object.
+type goNames struct {
+	vars    map[string]string            // python name -> go name
+	funcs   map[string]string            // python name -> go name
+	classes map[string]string            // python name -> go name
+	methods map[string]map[string]string // class name -> method name -> go name
+	renames []rename
+}
+
+// a symbol renamed to avoid a collision
+type rename struct {
+	Name   string // python name, like Class.method for methods
+	GoName string // go name it gets
+	Taken  string // go name it collides with
+}
+
+func (r rename) String() string {
+	return r.Name + " -> " + r.GoName + " (" + r.Taken + " is taken)"
+}
+
+var (
+	reservedNames       = []string{"LLGoPackage", "GopPackage"}
+	reservedMethodNames = []string{"Object"}
+	funcVariants        = []string{"Kw", "Typed"}
+	methodVariants      = []string{"Kw"}
+)
+
+// go names of the symbols of a module that are generated
+func resolveNames(mod *symbol.Module) (names goNames) {
+	public := func(name string) bool { return name != "" && name[0] != '_' }
+	scope := newNameScope(reservedNames)
+	resolve := func(scope nameScope, m map[string]string, qualified, name string, variants []string) {
+		if _, ok := m[name]; ok {
+			return // the same python name is generated once
+		}
+		goName, taken := scope.claim(genName(name), variants)
+		if taken != "" {
+			names.renames = append(names.renames, rename{Name: qualified, GoName: goName, Taken: taken})
+		}
+		m[name] = goName
+	}
+	names.vars = make(map[string]string)
+	for _, sym := range mod.Variables {
+		if public(sym.Name) {
+			resolve(scope, names.vars, sym.Name, sym.Name, nil)
+		}
+	}
+	names.funcs = make(map[string]string)
+	for _, sym := range mod.Functions {
+		if boundFamily(sym, false) != nil {
+			resolve(scope, names.funcs, sym.Name, sym.Name, funcVariants)
+		}
+	}
+	names.classes = make(map[string]string)
+	names.methods = make(map[string]map[string]string)
+	for _, cls := range mod.Classes {
+		if !public(cls.Name) {
+			continue
+		}
+		if _, ok := names.classes[cls.Name]; ok {
+			continue
+		}
+		resolve(scope, names.classes, cls.Name, cls.Name, nil)
+		methods := make(map[string]string)
+		names.methods[cls.Name] = methods
+		methodScope := newNameScope(reservedMethodNames)
+		for _, syms := range [][]*symbol.Symbol{cls.Methods, cls.ClassMethods, cls.StaticMethods} {
+			for _, sym := range syms {
+				if boundFamily(sym, true) != nil {
+					resolve(methodScope, methods, cls.Name+"."+sym.Name, sym.Name, methodVariants)
+				}
+			}
+		}
+	}
+	return
+}
+
+// the overload family of a function or method that is generated, nil if
+// it is skipped, see genFunc
+func boundFamily(sym *symbol.Symbol, method bool) [][]*pysig.Arg {
+	if sym.Name == "" || sym.Name[0] == '_' || sym.Sig == "" || sigError(sym) != nil {
+		return nil
+	}
+	family := overloadFamily(sym, dropSelfIf(method, symbolArgs(sym)), method)
+	if paramProblem(family) != "" {
+		return nil
+	}
+	return family
+}
+
+// the go name of a python name in m, genName(name) if it is not resolved
+func resolved(m map[string]string, name string) string {
+	if goName, ok := m[name]; ok {
+		return goName
+	}
+	return genName(name)
+}
+
+// go names taken in a package or by the methods of a type
+type nameScope map[string]bool
+
+func newNameScope(reserved []string) nameScope {
+	scope := make(nameScope)
+	for _, name := range reserved {
+		scope[name] = true
+	}
+	return scope
+}
+
+// claim takes the first free name of goName, goName_2, goName_3... with
+// its variants, and returns it with the name taken before, "" if none
+func (s nameScope) claim(goName string, variants []string) (string, string) {
+	taken := ""
+	name := goName
+	for i := 2; ; i++ {
+		if t := s.taken(name, variants); t == "" {
+			break
+		} else if taken == "" {
+			taken = t
+		}
+		name = goName + "_" + strconv.Itoa(i)
+	}
+	s[name] = true
+	for _, v := range variants {
+		s[name+v] = true
+	}
+	return name, taken
+}
+
+// the first taken name of name and its variants, "" if none
+func (s nameScope) taken(name string, variants []string) string {
+	if s[name] {
+		return name
+	}
+	for _, v := range variants {
+		if s[name+v] {
+			return name + v
+		}
+	}
+	return ""
+}
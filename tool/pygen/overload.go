@@ -0,0 +1,220 @@
+package pygen
+
+import (
+	"go/ast"
+	"sort"
+	"strings"
+	"github.com/goplus/llpyg/symbol"
+	"github.com/goplus/llpyg/tool/pysig"
+)
+
+// A python callable becomes a Go+ overload family if it can be called in
+// several ways, one function for each number of positional arguments:
+//
+//	func_d(a=1, b=2.0) -> FuncD__0(), FuncD__1(a), FuncD__2(a, b)
+//	range(stop), range(start, stop[, step]) -> Range__0(stop), Range__1(start, stop), Range__2(start, stop, step)
+//	arange([start,] stop[, step]) -> Arange__0(stop), Arange__1(start, stop), Arange__2(start, stop, step)
+//
+// The call forms are the distinct Sigs of the symbol, from @overload defs
+// or the docstring, if its signature is one of them, or args otherwise.
+// Each form is also called without its trailing optional parameters, see
+// optionalParams. The forms are sorted by the number of parameters, the
+// first one of a number wins. method tells to drop self of the forms.
+func overloadFamily(sym *symbol.Symbol, args []*pysig.Arg, method bool) (family [][]*pysig.Arg) {
+	forms := [][]*pysig.Arg{args}
+	if sigs := callForms(sym); len(sigs) > 1 {
+		forms = forms[:0]
+		for _, sig := range sigs {
+			forms = append(forms, dropSelfIf(method, pysig.Parse(sig)))
+		}
+	}
+	for _, form := range forms {
+		if form = withoutLeadingOptional(form); form != nil {
+			forms = append(forms, form)
+		}
+	}
+	type key struct {
+		n        int
+		variadic bool
+	}
+	seen := make(map[key]bool)
+	for _, form := range forms {
+		for omit := optionalParams(form); omit >= 0; omit-- {
+			variant := omitParams(form, omit)
+			n, variadic := positionalArgs(variant)
+			if k := (key{n, variadic}); !seen[k] {
+				seen[k] = true
+				family = append(family, variant)
+			}
+		}
+	}
+	if len(family) > len(overloadIndex) {
+		return [][]*pysig.Arg{args}
+	}
+	sort.SliceStable(family, func(i, j int) bool {
+		ni, vi := positionalArgs(family[i])
+		nj, vj := positionalArgs(family[j])
+		return ni < nj || ni == nj && !vi && vj
+	})
+	return family
+}
+
+// the form without the optional parameters in brackets before a required
+// one, e.g. (stop[, step]) of ([start,] stop[, step]), nil if there are none
+func withoutLeadingOptional(args []*pysig.Arg) []*pysig.Arg {
+	last := -1 // the last required positional parameter
+	for i, arg := range args {
+		if isPositional(arg) && arg.DefVal == "" && !arg.Optional {
+			last = i
+		}
+	}
+	var list []*pysig.Arg
+	for i, arg := range args {
+		if i < last && isPositional(arg) && arg.Optional {
+			if list == nil {
+				list = append(make([]*pysig.Arg, 0, len(args)), args[:i]...)
+			}
+			continue
+		}
+		if list != nil {
+			list = append(list, arg)
+		}
+	}
+	return list
+}
+
+// the distinct call forms of a symbol whose signature is one of them, the
+// ones that can't be parsed are dropped
+func callForms(sym *symbol.Symbol) (sigs []string) {
+	if len(sym.Sigs) < 2 || sym.Sigs[0] != sym.Sig {
+		return nil
+	}
+	for _, sig := range sym.Sigs {
+		if pysig.Check(sig) == nil && !containsString(sigs, sig) {
+			sigs = append(sigs, sig)
+		}
+	}
+	return
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func dropSelfIf(method bool, args []*pysig.Arg) []*pysig.Arg {
+	if method {
+		return dropSelf(args)
+	}
+	return args
+}
+
+// the number of trailing optional parameters, with a default value or in
+// brackets like f(a[, b]), that can be left out. Functions with *args
+// are always called with all of them.
+func optionalParams(args []*pysig.Arg) int {
+	n := 0
+	for _, arg := range args {
+		switch arg.Kind {
+		case pysig.VarPositional:
+			return 0
+		case pysig.KeywordOnly, pysig.VarKeyword:
+			continue
+		}
+		if arg.DefVal != "" || arg.Optional {
+			n++
+		} else {
+			n = 0
+		}
+	}
+	return n
+}
+
+// args without the last n positional ones
+func omitParams(args []*pysig.Arg, n int) []*pysig.Arg {
+	if n == 0 {
+		return args
+	}
+	list := make([]*pysig.Arg, 0, len(args)-n)
+	for i := len(args) - 1; i >= 0; i-- {
+		if isPositional(args[i]) && n > 0 {
+			n--
+			continue
+		}
+		list = append(list, args[i])
+	}
+	for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+		list[i], list[j] = list[j], list[i]
+	}
+	return list
+}
+
+func isPositional(arg *pysig.Arg) bool {
+	switch arg.Kind {
+	case pysig.VarPositional, pysig.KeywordOnly, pysig.VarKeyword:
+		return false
+	}
+	return true
+}
+
+// the number of positional parameters, and whether there is *args
+func positionalArgs(args []*pysig.Arg) (n int, variadic bool) {
+	for _, arg := range args {
+		if isPositional(arg) {
+			n++
+		} else if arg.Kind == pysig.VarPositional {
+			variadic = true
+		}
+	}
+	return
+}
+
+// whether two forms have the same positional parameters
+func sameArgs(a, b []*pysig.Arg) bool {
+	na, va := positionalArgs(a)
+	nb, vb := positionalArgs(b)
+	return na == nb && va == vb
+}
+
+const overloadIndex = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// name of the i-th function of a family of n, the name itself if n is 1
+func overloadName(name string, i, n int) string {
+	if n <= 1 {
+		return name
+	}
+	return name + "__" + overloadIndex[i:i+1]
+}
+
+// the first function of a family has the doc of the symbol, the others
+// tell the parameters they also pass, or the form they call
+func (ctx *context) genOverloadDoc(sym *symbol.Symbol, goName string, family [][]*pysig.Arg, i int) []*ast.Comment {
+	if i == 0 {
+		return ctx.genSymDoc(sym)
+	}
+	first, form := positionalNames(family[0]), positionalNames(family[i])
+	text := " but calls " + sym.Name + "(" + strings.Join(form, ", ") + ")."
+	if len(first) < len(form) && strings.Join(form[:len(first)], ",") == strings.Join(first, ",") {
+		text = " but also passes " + strings.Join(form[len(first):], ", ") + "."
+	}
+	return []*ast.Comment{
+		{Text: "// " + overloadName(goName, i, len(family)) + " is like " + overloadName(goName, 0, len(family)) + text},
+		emptyCommentLine,
+	}
+}
+
+// names of the positional parameters, *args for the variadic one
+func positionalNames(args []*pysig.Arg) (names []string) {
+	for _, arg := range args {
+		if isPositional(arg) {
+			names = append(names, arg.Name)
+		} else if arg.Kind == pysig.VarPositional {
+			names = append(names, "*"+arg.Name)
+		}
+	}
+	return
+}
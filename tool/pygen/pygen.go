@@ -1,18 +1,16 @@
 package pygen
 
 import (
-	"bytes"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
-	"encoding/json"
 	"strings"
 	"log"
 	"strconv"
 	"go/ast"
 	"go/types"
 	"github.com/goplus/gogen"
+	"github.com/goplus/llpyg/tool/pydoc"
 	"github.com/goplus/llpyg/tool/pysig"
 	"github.com/goplus/llpyg/symbol"
 )
@@ -24,50 +22,79 @@ type context struct {
 	objPtr *types.Pointer
 	ret    *types.Tuple
 	py     gogen.PkgRef
-	skips  []symbol.Symbol
+	report *ModuleReport
+	opts   Options
+	name   string            // python module name
+	links  map[string]string // python name -> go doc link, e.g. add -> [Add]
+	names  goNames           // go names of the symbols, see resolveNames
+}
+
+// Options of generating bindings.
+type Options struct {
+	DocSummary bool  // keep only the summary paragraph of docstrings
+	Links      Links // doc links of the other modules bound in the same run
+	Typed      bool    // also generate typed wrappers of functions, see genFuncTyped
+	Report     *Report // if not nil, the report of each module is added to it
 }
 
 
+// GenLLGoBindings generates LLGo bindings of a python module,
+// getting its symbols from the pydump tool.
 func GenLLGoBindings(moduleName string, outFile io.Writer) {
-	// get module symbols info from pydump
-	mod, err := pydump(moduleName)
+	GenLLGoBindingsWith(DefaultDumper, moduleName, outFile)
+}
+
+// GenLLGoBindingsFromDump generates LLGo bindings from a symbol.Module JSON file
+// saved as dumpDir/moduleName.json, e.g. by `pydump moduleName > numpy.json`.
+// It needs neither pydump nor Python.
+func GenLLGoBindingsFromDump(dumpDir, moduleName string, outFile io.Writer) {
+	GenLLGoBindingsWith(&DirDumper{Dir: dumpDir}, moduleName, outFile)
+}
+
+// GenLLGoBindingsWith generates LLGo bindings of a python module,
+// getting its symbols from dumper.
+func GenLLGoBindingsWith(dumper Dumper, moduleName string, outFile io.Writer) {
+	GenLLGoBindingsWithOptions(dumper, moduleName, outFile, nil)
+}
+
+// GenLLGoBindingsWithOptions is GenLLGoBindingsWith with options, nil for the defaults.
+func GenLLGoBindingsWithOptions(dumper Dumper, moduleName string, outFile io.Writer, opts *Options) {
+	mod, err := dumper.Dump(moduleName)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
+		if opts != nil {
+			opts.Report.Add(failedModule(moduleName, err))
+		}
 		return
 	}
+	genBindings(mod, outFile, opts)
+}
 
+func genBindings(mod symbol.Module, outFile io.Writer, opts *Options) {
 	// create go package
 	ctx := createGoPackage(mod)
+	if opts != nil {
+		ctx.opts = *opts
+	}
 
 	// generate go code
 	ctx.genMod(ctx.pkg, &mod)
 
-	skips := ctx.skips
-	if n := len(skips); n > 0 {
-		log.Printf("==> Skip %d symbols:\n%v\n", n, skips)
+	ctx.reportRenames()
+	ctx.logReport()
+	if renames := ctx.names.renames; len(renames) > 0 {
+		log.Printf("==> Rename %d symbols to avoid name collisions:\n%v\n", len(renames), renames)
+	}
+	if mod.Static {
+		names := staticNames(&mod)
+		log.Printf("==> %d symbols of %s from static analysis of %s, not imported:\n%v\n", len(names), mod.Name, mod.File, names)
 	}
 
 	// write to file
 	ctx.pkg.WriteTo(outFile)
-}
-
-func pydump(moduleName string) (mod symbol.Module, err error) {
-	var out bytes.Buffer
-	cmd := exec.Command("pydump", moduleName)
-	cmd.Stdout = &out
-	cmd.Stderr = os.Stderr
-	err = cmd.Run()
-	if err != nil {
-		return mod, fmt.Errorf("pydump %s failed: %w", moduleName, err)
-	}
-	err = json.Unmarshal(out.Bytes(), &mod)
-	if err != nil {
-		return mod, fmt.Errorf("unmarshal %s failed: %w", moduleName, err)
-	}
-	if mod.Name != moduleName {
-		return mod, fmt.Errorf("import module failed: %s", moduleName)
+	if opts != nil {
+		opts.Report.Add(ctx.report)
 	}
-	return mod, nil
 }
 
 func createGoPackage(mod symbol.Module) (ctx *context) {
@@ -89,11 +116,50 @@ func createGoPackage(mod symbol.Module) (ctx *context) {
 	obj := py.Ref("Object").(*types.TypeName).Type().(*types.Named)
 	objPtr := types.NewPointer(obj)
 	ret := types.NewTuple(pkg.NewParam(0, "", objPtr)) // return *py.Object
-	ctx = &context{pkg, obj, objPtr, ret, py, nil}
+	report := &ModuleReport{Module: mod.Name, Static: mod.Static}
+	ctx = &context{pkg: pkg, obj: obj, objPtr: objPtr, ret: ret, py: py, name: mod.Name, report: report}
 	return ctx
 }
 
+// public names of a module, classes with their methods, e.g. Foo.bar
+func staticNames(mod *symbol.Module) (names []string) {
+	public := func(name string) bool { return name != "" && name[0] != '_' }
+	for _, syms := range [][]*symbol.Symbol{mod.Variables, mod.Functions} {
+		for _, sym := range syms {
+			if public(sym.Name) {
+				names = append(names, sym.Name)
+			}
+		}
+	}
+	for _, cls := range mod.Classes {
+		if !public(cls.Name) {
+			continue
+		}
+		names = append(names, cls.Name)
+		for _, syms := range [][]*symbol.Symbol{cls.Methods, cls.ClassMethods, cls.StaticMethods} {
+			for _, sym := range syms {
+				if public(sym.Name) {
+					names = append(names, cls.Name+"."+sym.Name)
+				}
+			}
+		}
+	}
+	return
+}
+
 func (ctx *context) genMod(pkg *gogen.Package, mod *symbol.Module) {
+	parseDocs(mod)
+	ctx.names = resolveNames(mod)
+	ctx.links = ctx.docLinks(mod)
+	// global variables
+	varMap := make(map[string]bool)
+	for _, sym := range mod.Variables {
+		if varMap[sym.Name] {
+			continue
+		}
+		varMap[sym.Name] = true
+		ctx.genVar(pkg, sym)
+	}
 	// global functions
 	funcMap := make(map[string]bool)
 	for _, sym := range mod.Functions {
@@ -103,7 +169,15 @@ func (ctx *context) genMod(pkg *gogen.Package, mod *symbol.Module) {
 		funcMap[sym.Name] = true
 		ctx.genFunc(pkg, sym)
 	}
-	// TODO: class, variable, etc.
+	// classes
+	classMap := make(map[string]bool)
+	for _, cls := range mod.Classes {
+		if classMap[cls.Name] {
+			continue
+		}
+		classMap[cls.Name] = true
+		ctx.genClass(pkg, cls)
+	}
 }
 
 
@@ -116,48 +190,118 @@ var goKeywords = map[string]bool{
     "struct": true, "interface": true, "map": true,
 }
 
-func (ctx *context) genParams(pkg *gogen.Package, sig string) (*types.Tuple, bool) {
-	args := pysig.Parse(sig)
+// parse the docstrings of a module that are not parsed yet
+func parseDocs(mod *symbol.Module) {
+	parse := func(sym *symbol.Symbol) {
+		if sym.Docstring == nil && sym.Doc != "" {
+			sym.Docstring = pydoc.Parse(sym.Doc)
+		}
+	}
+	for _, sym := range mod.Functions {
+		parse(sym)
+	}
+	for _, cls := range mod.Classes {
+		if cls.Docstring == nil && cls.Doc != "" {
+			cls.Docstring = pydoc.Parse(cls.Doc)
+		}
+		for _, syms := range [][]*symbol.Symbol{cls.Methods, cls.ClassMethods, cls.StaticMethods} {
+			for _, sym := range syms {
+				parse(sym)
+			}
+		}
+	}
+}
+
+// arguments of a symbol, from the structured parameters dumped by inspect,
+// or parsed from the signature string when they are unknown. The types of
+// the parsed ones that have none are taken from the docstring.
+func symbolArgs(sym *symbol.Symbol) []*pysig.Arg {
+	if sym.Params == nil {
+		args := pysig.Parse(sym.Sig)
+		if sym.Docstring != nil {
+			for _, arg := range args {
+				for _, param := range sym.Docstring.Params {
+					if arg.Type == "" && param.Name == arg.Name {
+						arg.Type = pydoc.Annotation(param.Type)
+					}
+				}
+			}
+		}
+		return args
+	}
+	args := make([]*pysig.Arg, len(sym.Params))
+	for i, param := range sym.Params {
+		args[i] = &pysig.Arg{Name: param.Name, Type: param.Annotation, DefVal: param.Default, Kind: param.Kind}
+	}
+	return args
+}
+
+// why the arguments of a symbol can't be parsed from its signature, nil if
+// they are dumped by inspect or can be parsed
+func sigError(sym *symbol.Symbol) error {
+	if sym.Params != nil {
+		return nil
+	}
+	return pysig.Check(sym.Sig)
+}
+
+// return annotation of a symbol, "" if unknown. Without an inspect
+// signature, it is the one of the signature string or the docstring.
+func symbolReturn(sym *symbol.Symbol) string {
+	if sym.Params == nil {
+		ret := pysig.ParseSignature(sym.Sig).Return
+		if d := sym.Docstring; ret == "" && d != nil && len(d.Returns) == 1 {
+			ret = pydoc.Annotation(d.Returns[0].Type)
+		}
+		return ret
+	}
+	return sym.Return
+}
+
+// genParams returns the positional parameters, whether *args is accepted,
+// and whether keyword-only arguments or **kwargs are accepted.
+func (ctx *context) genParams(pkg *gogen.Package, args []*pysig.Arg) (*types.Tuple, bool, bool) {
 	if len(args) == 0 {
-		return nil, false
+		return nil, false, false
 	}
-	n := len(args)
 	objPtr := ctx.objPtr
-	list := make([]*types.Var, 0, n)
-    listNum := 0
-	for i := 0; i < n; i++ {
-		name := strings.TrimSpace(args[i].Name)
-		if name == "/" || name == "" || name == "," {
+	list := make([]*types.Var, 0, len(args))
+	listNum := 0
+	variadic, kwargs := false, false
+	for _, arg := range args {
+		switch arg.Kind {
+		case pysig.VarPositional:
+			variadic = true
 			continue
+		case pysig.KeywordOnly, pysig.VarKeyword:
+			kwargs = true
+			continue
+		}
+		name := strings.TrimSpace(arg.Name)
+		// go keyword
+		if goKeywords[name] {
+			name = name + "_"
 		}
-        // go keyword
-        if goKeywords[name] {
-            name = name + "_"
-        }
 		if name[0] == '(' {
 			// (a1, a2, ...) -> list_0
 			name = "list_" + strconv.Itoa(listNum)
 			listNum++
 		}
-		if name == "*" || name == "\\*" {
-			// TODO: support kwargs
-			break
-		}
-		if strings.HasPrefix(name, "*") {
-			if name[1] != '*' {
-				list = append(list, vArgs)  // *args
-				return types.NewTuple(list...), true
-			}
-			// TODO: support **kwargs
-			return types.NewTuple(list...), false
-		}
 		list = append(list, pkg.NewParam(0, ctx.genName(name, 0), objPtr))
 	}
-	return types.NewTuple(list...), false
+	if variadic {
+		list = append(list, vArgs) // *args
+	}
+	return types.NewTuple(list...), variadic, kwargs
 }
 
 // python name to go name
 func (ctx *context) genName(name string, idxDontTitle int) string {
+	return pyToGoName(name, idxDontTitle)
+}
+
+// underline to camel case, the part idxDontTitle is not titled, -1 for none
+func pyToGoName(name string, idxDontTitle int) string {
 	lastIdx := len(name) - 1
 	for lastIdx >= 0 && name[lastIdx] == '_' {
 		lastIdx--
@@ -181,15 +325,39 @@ func (ctx *context) genName(name string, idxDontTitle int) string {
 	return name
 }
 
-// Generate documentation comments from the symbol's doc string
+// doc comments of a function or method: the doc string and the return
+// annotation, followed by an empty line if not empty
+func (ctx *context) genSymDoc(sym *symbol.Symbol) []*ast.Comment {
+	docList := ctx.genDoc(sym.Doc)
+	if ret := symbolReturn(sym); ret != "" {
+		if len(docList) > 0 {
+			docList = append(docList, emptyCommentLine)
+		}
+		docList = append(docList, &ast.Comment{Text: "// Returns: " + ret})
+	}
+	if len(docList) > 0 {
+		docList = append(docList, emptyCommentLine)
+	}
+	return docList
+}
+
+// Generate documentation comments from the symbol's doc string,
+// converted to go doc, see pydoc.GoDoc
 func (ctx *context) genDoc(doc string) []*ast.Comment {
-	if doc == "" {
+	if ctx.opts.DocSummary {
+		doc = pydoc.Parse(doc).Summary
+	}
+	if doc = pydoc.GoDoc(doc, ctx.docLink); doc == "" {
 		return make([]*ast.Comment, 0, 4)
 	}
 	lines := strings.Split(doc, "\n")
 	list := make([]*ast.Comment, len(lines), len(lines)+4)
 	for i, line := range lines {
-		list[i] = &ast.Comment{Text: "// " + line}
+		if line == "" {
+			list[i] = emptyCommentLine
+		} else {
+			list[i] = &ast.Comment{Text: "// " + line}
+		}
 	}
 	return list
 }
@@ -1,32 +1,22 @@
 package pygen
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"go/format"
+	"io"
 	"os"
 	"os/exec"
 	"testing"
+	"strings"
 	"path/filepath"
-	"runtime"
+	"github.com/goplus/llpyg/symbol"
+	"github.com/goplus/llpyg/tool/pysig"
 )
 
-func prepareEnv(dir string) {
-	os.Setenv("PYTHONPATH", dir)
-	pyHome := os.Getenv("PYTHONHOME")
-	if pyHome == "" {
-		return
-	}
-	// lib
-	switch runtime.GOOS {
-	case "darwin":
-		os.Setenv("DYLD_LIBRARY_PATH", pyHome+"/lib")
-	case "linux":
-		os.Setenv("LD_LIBRARY_PATH", pyHome+"/lib")
-	}
-}
-
 func TestGenFunc(t *testing.T) {
-	prepareEnv("./testdata/func")
-	mod, err := pydump("demo")
+	mod, err := readDump("./testdata/func", "demo")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -41,6 +31,320 @@ func TestGenFunc(t *testing.T) {
 	t.Logf("test gen func pass")
 }
 
+func TestGenClass(t *testing.T) {
+	mod, err := readDump("./testdata/class", "demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := createGoPackage(mod)
+	for _, cls := range mod.Classes {
+		ctx.genClass(ctx.pkg, cls)
+	}
+	err = compareWithExpected(t, ctx, "testdata/class/expect.go")
+	if err != nil {
+		t.Fatalf("test gen class failed: %v", err)
+	}
+	t.Logf("test gen class pass")
+}
+
+func TestGenVar(t *testing.T) {
+	mod, err := readDump("./testdata/var", "demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := createGoPackage(mod)
+	for _, sym := range mod.Variables {
+		ctx.genVar(ctx.pkg, sym)
+	}
+	err = compareWithExpected(t, ctx, "testdata/var/expect.go")
+	if err != nil {
+		t.Fatalf("test gen var failed: %v", err)
+	}
+	t.Logf("test gen var pass")
+}
+
+func TestGenKwargs(t *testing.T) {
+	mod, err := readDump("./testdata/kwargs", "demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := createGoPackage(mod)
+	for _, sym := range mod.Functions {
+		ctx.genFunc(ctx.pkg, sym)
+	}
+	for _, cls := range mod.Classes {
+		ctx.genClass(ctx.pkg, cls)
+	}
+	err = compareWithExpected(t, ctx, "testdata/kwargs/expect.go")
+	if err != nil {
+		t.Fatalf("test gen kwargs failed: %v", err)
+	}
+	t.Logf("test gen kwargs pass")
+}
+
+func TestGenTyped(t *testing.T) {
+	mod, err := readDump("./testdata/typed", "demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := createGoPackage(mod)
+	ctx.opts.Typed = true
+	for _, sym := range mod.Functions {
+		ctx.genFunc(ctx.pkg, sym)
+	}
+	err = compareWithExpected(t, ctx, "testdata/typed/expect.go")
+	if err != nil {
+		t.Fatalf("test gen typed failed: %v", err)
+	}
+	t.Logf("test gen typed pass")
+}
+
+func TestGenOverload(t *testing.T) {
+	mod, err := readDump("./testdata/overload", "demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := createGoPackage(mod)
+	ctx.genMod(ctx.pkg, &mod)
+	err = compareWithExpected(t, ctx, "testdata/overload/expect.go")
+	if err != nil {
+		t.Fatalf("test gen overload failed: %v", err)
+	}
+	t.Logf("test gen overload pass")
+}
+
+func TestGenNames(t *testing.T) {
+	mod, err := readDump("./testdata/names", "demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := createGoPackage(mod)
+	ctx.genMod(ctx.pkg, &mod)
+	err = compareWithExpected(t, ctx, "testdata/names/expect.go")
+	if err != nil {
+		t.Fatalf("test gen names failed: %v", err)
+	}
+	want := "[Pi -> Pi_2 (Pi is taken) Sum -> Sum_2 (Sum is taken) sum_kw -> SumKw_2 (SumKw is taken) " +
+		"funcA -> FuncA_2 (FuncA is taken) FuncA -> FuncA_3 (FuncA is taken) " +
+		"LLGoPackage -> LLGoPackage_2 (LLGoPackage is taken) Array -> Array_2 (Array is taken) " +
+		"Array.Size -> Size_2 (Size is taken) Array.object -> Object_2 (Object is taken)]"
+	if got := fmt.Sprint(ctx.names.renames); got != want {
+		t.Fatalf("renames = %s", got)
+	}
+	t.Logf("test gen names pass")
+}
+
+func TestGenDoc(t *testing.T) {
+	mod, err := readDump("./testdata/doc", "demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := createGoPackage(mod)
+	ctx.genMod(ctx.pkg, &mod)
+	err = compareWithExpected(t, ctx, "testdata/doc/expect.go")
+	if err != nil {
+		t.Fatalf("test gen doc failed: %v", err)
+	}
+	t.Logf("test gen doc pass")
+}
+
+func TestDocSummary(t *testing.T) {
+	mod, err := readDump("./testdata/doc", "demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	genBindings(mod, &out, &Options{DocSummary: true})
+	got := out.String()
+	if !strings.Contains(got, "// Add arguments element-wise.\n//\n// Returns: ndarray\n") {
+		t.Fatalf("summary of add not found:\n%s", got)
+	}
+	if strings.Contains(got, "# Parameters") || strings.Contains(got, "New in version") {
+		t.Fatalf("doc is not only the summary:\n%s", got)
+	}
+}
+
+func TestDocLinks(t *testing.T) {
+	links := make(Links)
+	links.Add(&symbol.Module{
+		Name:      "demo.random",
+		Functions: []*symbol.Symbol{{Name: "randint", Sig: "(a, b)"}, {Name: "_seed", Sig: "()"}},
+		Classes: []*symbol.Class{{
+			Name:    "Rand",
+			Methods: []*symbol.Symbol{{Name: "seed", Sig: "(self, a)"}},
+		}},
+	}, "example.com/demo/random")
+	mod := symbol.Module{
+		Name: "demo.linalg",
+		Functions: []*symbol.Symbol{{
+			Name: "add",
+			Sig:  "(a, b)",
+			Doc:  "Add, see :func:`add`, :func:`demo.random.randint`, `random.Rand`, :meth:`~random.Rand.seed`, :mod:`demo.random` and :func:`random._seed`.",
+		}},
+	}
+	var out bytes.Buffer
+	genBindings(mod, &out, &Options{Links: links})
+	want := "// Add, see [Add], [example.com/demo/random.Randint], [example.com/demo/random.Rand], " +
+		"[example.com/demo/random.Rand.Seed], [example.com/demo/random] and random._seed.\n"
+	if !strings.Contains(out.String(), want) {
+		t.Fatalf("doc links not found:\n%s", out.String())
+	}
+}
+
+type mapDumper map[string]symbol.Module
+
+func (d mapDumper) Dump(moduleName string) (symbol.Module, error) {
+	mod, ok := d[moduleName]
+	if !ok {
+		return mod, fmt.Errorf("no module %s", moduleName)
+	}
+	return mod, nil
+}
+
+func TestReport(t *testing.T) {
+	mod := symbol.Module{
+		Name: "demo",
+		Functions: []*symbol.Symbol{
+			{Name: "sum", Sig: "(a)"},
+			{Name: "Sum", Sig: "(a, b)"},
+			{Name: "_private", Sig: "()"},
+			{Name: "builtin", Sig: ""},
+			{Name: "bad", Sig: "(a-b)"},
+			{Name: "unclosed", Sig: "(a=[1[, b])"},
+			{Name: "odd", Sig: "(x)", Params: []*symbol.Param{{Name: "x", Kind: "POSITIONAL"}}},
+		},
+		Classes: []*symbol.Class{{
+			Name:    "Rand",
+			Methods: []*symbol.Symbol{{Name: "seed", Sig: "(self, a)"}, {Name: "__init__", Sig: "(self)"}},
+		}},
+	}
+	report := &Report{}
+	opts := &Options{Report: report}
+	dumper := mapDumper{"demo": mod}
+	GenLLGoBindingsWithOptions(dumper, "demo", io.Discard, opts)
+	GenLLGoBindingsWithOptions(dumper, "demo.missing", io.Discard, opts)
+	got, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"modules":[{"module":"demo",` +
+		`"generated":[{"name":"sum","goName":"Sum"},{"name":"Sum","goName":"Sum_2","reason":"name collision: Sum is taken"},` +
+		`{"name":"Rand","goName":"Rand"},{"name":"Rand.seed","goName":"Seed"}],` +
+		`"skipped":[{"name":"_private","reason":"private name"},{"name":"builtin","reason":"no signature"},` +
+		`{"name":"bad","reason":"unparsable signature: a-b"},` +
+		`{"name":"unclosed","reason":"unparsable signature: unbalanced brackets in the parameter list"},` +
+		`{"name":"odd","reason":"unsupported parameter kind: x is POSITIONAL"},{"name":"Rand.__init__","reason":"private name"}]},` +
+		`{"module":"demo.missing","reason":"import failure: no module demo.missing"}]}`
+	if string(got) != want {
+		t.Fatalf("report = %s", got)
+	}
+}
+
+func TestGenWithDumper(t *testing.T) {
+	mod, err := (&DirDumper{Dir: "./testdata/class"}).Dump("demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	GenLLGoBindingsWith(mapDumper{"demo": mod}, "demo", &out)
+	got, err := format.Source(out.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile("testdata/class/expect.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("get not match expected:\n%s", got)
+	}
+}
+
+func TestSymbolArgs(t *testing.T) {
+	cases := []struct {
+		params []*symbol.Param
+		sig    string
+		want   string
+	}{
+		{nil, "(a, b=1)", "a b"},
+		{nil, "(a, /, *args, b, **kw)", "a *args b **kw"},
+		{[]*symbol.Param{}, "()", ""},
+		{[]*symbol.Param{
+			{Name: "x1", Kind: symbol.PositionalOnly},
+			{Name: "x2", Kind: symbol.PositionalOnly},
+			{Name: "out", Kind: symbol.PositionalOrKeyword, Default: "None"},
+			{Name: "where", Kind: symbol.KeywordOnly, Default: "True"},
+			{Name: "kwargs", Kind: symbol.VarKeyword},
+		}, "", "x1 x2 out where **kwargs"},
+		{[]*symbol.Param{
+			{Name: "a", Kind: symbol.PositionalOnly},
+			{Name: "args", Kind: symbol.VarPositional},
+			{Name: "b", Kind: symbol.KeywordOnly, Annotation: "int"},
+		}, "", "a *args b"},
+	}
+	for _, c := range cases {
+		args := symbolArgs(&symbol.Symbol{Params: c.params, Sig: c.sig})
+		names := make([]string, len(args))
+		for i, arg := range args {
+			names[i] = arg.Name
+			switch arg.Kind {
+			case pysig.VarPositional:
+				names[i] = "*" + arg.Name
+			case pysig.VarKeyword:
+				names[i] = "**" + arg.Name
+			}
+		}
+		if got := strings.Join(names, " "); got != c.want {
+			t.Fatalf("symbolArgs(%v) = %q, want %q", c.sig, got, c.want)
+		}
+	}
+}
+
+func TestOptionalParams(t *testing.T) {
+	cases := []struct {
+		sig  string
+		want int
+	}{
+		{"(a=1, b=2.0)", 2},
+		{"(start, unit='s')", 1},
+		{"(a, b[, c[, d]])", 2},
+		{"(a=1, b, c=3)", 1},
+		{"(a, b=1, *, c=2, **kw)", 1},
+		{"(a=1, *args)", 0},
+		{"(a, b)", 0},
+	}
+	for _, c := range cases {
+		if got := optionalParams(pysig.Parse(c.sig)); got != c.want {
+			t.Fatalf("optionalParams(%v) = %d, want %d", c.sig, got, c.want)
+		}
+	}
+}
+
+func TestDocTypes(t *testing.T) {
+	mod := &symbol.Module{Functions: []*symbol.Symbol{{
+		Name: "add",
+		Sig:  "(x1, x2, out=None)",
+		Doc:  "Add.\n\nParameters\n----------\nx1, x2 : array_like\nout : ndarray, optional\n\nReturns\n-------\nndarray\n",
+	}}}
+	parseDocs(mod)
+	sym := mod.Functions[0]
+	var types []string
+	for _, arg := range symbolArgs(sym) {
+		types = append(types, arg.Type)
+	}
+	if got := strings.Join(types, " "); got != "array_like array_like ndarray" {
+		t.Fatalf("types = %q", got)
+	}
+	if ret := symbolReturn(sym); ret != "ndarray" {
+		t.Fatalf("symbolReturn = %q", ret)
+	}
+	// inspect signatures are exact
+	sym.Params = []*symbol.Param{{Name: "x1", Kind: symbol.PositionalOrKeyword}}
+	if args := symbolArgs(sym); args[0].Type != "" || symbolReturn(sym) != "" {
+		t.Fatalf("types of an inspect signature are taken from the docstring")
+	}
+}
+
 func compareWithExpected(t *testing.T, ctx *context, expectedPath string) error {
 	outFilePath := "./temp/actual_git.go"
 	dir := filepath.Dir(outFilePath)
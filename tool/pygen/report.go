@@ -0,0 +1,148 @@
+package pygen
+
+import (
+	"encoding/json"
+	"errors"
+	"go/token"
+	"log"
+	"os"
+	"strings"
+	"github.com/goplus/llpyg/tool/pysig"
+)
+
+// Report tells what is generated of the modules of a run, it is written
+// as llpyg-report.json next to the generated go module.
+type Report struct {
+	Modules []*ModuleReport `json:"modules"`
+}
+
+// ModuleReport lists the symbols of a module that are generated, skipped
+// or failed, with the reason of each, or tells why the module failed.
+type ModuleReport struct {
+	Module    string          `json:"module"`
+	Static    bool            `json:"static,omitempty"` // from static analysis of the source, see symbol.Module
+	Reason    string          `json:"reason,omitempty"` // why the module failed, e.g. import failure: timeout after 120s
+	Generated []*SymbolReport `json:"generated,omitempty"`
+	Skipped   []*SymbolReport `json:"skipped,omitempty"`
+	Failed    []*SymbolReport `json:"failed,omitempty"`
+}
+
+// SymbolReport is a symbol of a ModuleReport.
+type SymbolReport struct {
+	Name   string `json:"name"`             // python name, like Class.method for methods
+	GoName string `json:"goName,omitempty"` // go name of a generated symbol, the name of its overload family
+	Reason string `json:"reason,omitempty"` // why it is skipped, failed or renamed
+}
+
+// Reasons of a report, followed by details like ": timeout after 120s".
+const (
+	ReasonPrivate     = "private name"
+	ReasonNoSignature = "no signature"
+	ReasonUnparsable  = "unparsable signature"
+	ReasonParamKind   = "unsupported parameter kind"
+	ReasonCollision   = "name collision"
+	ReasonImport      = "import failure"
+)
+
+// Add adds the report of a module.
+func (r *Report) Add(m *ModuleReport) {
+	if r != nil {
+		r.Modules = append(r.Modules, m)
+	}
+}
+
+// WriteFile writes the report as indented JSON.
+func (r *Report) WriteFile(file string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(data, '\n'), 0644)
+}
+
+// report of a module that failed to dump
+func failedModule(moduleName string, err error) *ModuleReport {
+	reason := err.Error()
+	var dumpErr *DumpError
+	if errors.As(err, &dumpErr) {
+		reason = dumpErr.Reason
+	}
+	return &ModuleReport{Module: moduleName, Reason: ReasonImport + ": " + reason}
+}
+
+func (ctx *context) generated(name, goName string) {
+	ctx.report.Generated = append(ctx.report.Generated, &SymbolReport{Name: name, GoName: goName})
+}
+
+func (ctx *context) skip(name, reason string) {
+	ctx.report.Skipped = append(ctx.report.Skipped, &SymbolReport{Name: name, Reason: reason})
+}
+
+func (ctx *context) fail(name string, err error) {
+	ctx.report.Failed = append(ctx.report.Failed, &SymbolReport{Name: name, Reason: err.Error()})
+}
+
+// the renames of the generated symbols are collisions
+func (ctx *context) reportRenames() {
+	reasons := make(map[string]string)
+	for _, r := range ctx.names.renames {
+		reasons[r.Name] = ReasonCollision + ": " + r.Taken + " is taken"
+	}
+	for _, sym := range ctx.report.Generated {
+		if reason, ok := reasons[sym.Name]; ok {
+			sym.Reason = reason
+		}
+	}
+}
+
+// log the symbols skipped for a reason other than their private name, and
+// the ones failed
+func (ctx *context) logReport() {
+	var lines []string
+	for _, sym := range ctx.report.Skipped {
+		if sym.Reason != ReasonPrivate {
+			lines = append(lines, "  "+sym.Name+": "+sym.Reason)
+		}
+	}
+	if n := len(lines); n > 0 {
+		log.Printf("==> Skip %d symbols:\n%s\n", n, strings.Join(lines, "\n"))
+	}
+	lines = lines[:0]
+	for _, sym := range ctx.report.Failed {
+		lines = append(lines, "  "+sym.Name+": "+sym.Reason)
+	}
+	if n := len(lines); n > 0 {
+		log.Printf("==> Failed to generate %d symbols:\n%s\n", n, strings.Join(lines, "\n"))
+	}
+}
+
+// why the parameters of an overload family can't be go parameters, "" if
+// they can: a kind that is not one of inspect, or a positional name that
+// is not an identifier, e.g. a-b of f(a-b) in a docstring
+func paramProblem(family [][]*pysig.Arg) string {
+	for _, args := range family {
+		for _, arg := range args {
+			if !knownKinds[arg.Kind] {
+				return ReasonParamKind + ": " + arg.Name + " is " + arg.Kind
+			}
+			if !isPositional(arg) {
+				continue
+			}
+			name := strings.TrimSpace(arg.Name)
+			if !token.IsIdentifier(name) && !goKeywords[name] && !strings.HasPrefix(name, "(") {
+				return ReasonUnparsable + ": " + name
+			}
+		}
+	}
+	return ""
+}
+
+// an empty kind is unknown, and taken as positional
+var knownKinds = map[string]bool{
+	"":                        true,
+	pysig.PositionalOnly:      true,
+	pysig.PositionalOrKeyword: true,
+	pysig.VarPositional:       true,
+	pysig.KeywordOnly:         true,
+	pysig.VarKeyword:          true,
+}
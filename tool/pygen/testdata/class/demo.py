@@ -0,0 +1,31 @@
+# demo for class
+
+class Animal:
+    """An animal."""
+
+    def __init__(self, name):
+        self.name = name
+
+    def speak(self, msg: str) -> str:
+        pass
+
+    def _sleep(self):
+        pass
+
+    @classmethod
+    def create(cls, name):
+        pass
+
+    @staticmethod
+    def kind():
+        pass
+
+class Dog(Animal):
+    def speak(self, msg: str) -> str:
+        pass
+
+    def fetch(self, item, /, times=1):
+        pass
+
+class _Hidden:
+    pass
@@ -0,0 +1,54 @@
+package demo
+
+import (
+	"github.com/goplus/lib/py"
+	_ "unsafe"
+)
+
+const GopPackage = true
+const LLGoPackage = "py.demo"
+
+// An animal.
+type Animal struct {
+	py.Object
+}
+
+// Returns: str
+//
+//llgo:link (*Animal).Speak py.Animal.speak
+func (a *Animal) Speak(msg *py.Object) *py.Object {
+	return nil
+}
+
+//llgo:link (*Animal).Create py.Animal.create
+func (a *Animal) Create(name *py.Object) *py.Object {
+	return nil
+}
+
+//llgo:link (*Animal).Kind py.Animal.kind
+func (a *Animal) Kind() *py.Object {
+	return nil
+}
+
+type Dog struct {
+	py.Object
+}
+
+// Returns: str
+//
+//llgo:link (*Dog).Speak py.Dog.speak
+func (d *Dog) Speak(msg *py.Object) *py.Object {
+	return nil
+}
+
+//llgo:link (*Dog).Fetch__0 py.Dog.fetch
+func (d *Dog) Fetch__0(item *py.Object) *py.Object {
+	return nil
+}
+
+// Fetch__1 is like Fetch__0 but also passes times.
+//
+//llgo:link (*Dog).Fetch__1 py.Dog.fetch
+func (d *Dog) Fetch__1(item *py.Object, times *py.Object) *py.Object {
+	return nil
+}
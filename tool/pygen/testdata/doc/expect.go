@@ -0,0 +1,66 @@
+package demo
+
+import (
+	"github.com/goplus/lib/py"
+	_ "unsafe"
+)
+
+const LLGoPackage = "py.demo"
+
+// Add arguments element-wise.
+//
+// # Parameters
+//
+//   - x1 (array_like): The arrays to be added.
+//   - x2 (array_like): The arrays to be added.
+//
+// # Returns
+//
+//   - add (ndarray): The sum of x1 and x2.
+//
+// # See Also
+//
+//   - [Subtract]: Subtract arguments.
+//
+// # Examples
+//
+//	>>> add(1.0, 4.0)
+//	5.0
+//
+// Returns: ndarray
+//
+//go:linkname Add py.add
+func Add(x1 *py.Object, x2 *py.Object) *py.Object
+
+// Subtract arguments, the inverse of [Add].
+//
+// New in version 1.2.
+//
+//go:linkname Subtract py.subtract
+func Subtract(x1 *py.Object, x2 *py.Object) *py.Object
+
+// A random generator.
+//
+// # Args
+//
+//   - seed (int): The initial seed, see [Rand.Seed].
+type Rand struct {
+	py.Object
+}
+
+// Reseed the generator.
+//
+// # Parameters
+//
+//   - a (int): The seed.
+//
+// # Returns
+//
+//   - int: The old seed, see [Rand.Seed].
+//
+// Returns: int
+//
+//llgo:link (*Rand).Seed py.Rand.seed
+func (r *Rand) Seed(a *py.Object) *py.Object {
+	return nil
+}
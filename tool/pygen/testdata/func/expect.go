@@ -5,19 +5,39 @@ import (
 	_ "unsafe"
 )
 
+const GopPackage = true
 const LLGoPackage = "py.demo"
 
 //go:linkname FuncA py.func_a
 func FuncA() *py.Object
 
+// Returns: int
+//
 //go:linkname FuncB py.func_b
 func FuncB() *py.Object
 
+// Returns: str
+//
 //go:linkname FuncC py.func_c
 func FuncC(a *py.Object, b *py.Object) *py.Object
 
-//go:linkname FuncD py.func_d
-func FuncD(a *py.Object, b *py.Object) *py.Object
+//go:linkname FuncD__0 py.func_d
+func FuncD__0() *py.Object
 
-//go:linkname FuncE py.func_e
-func FuncE(start *py.Object, unit *py.Object) *py.Object
+// FuncD__1 is like FuncD__0 but also passes a.
+//
+//go:linkname FuncD__1 py.func_d
+func FuncD__1(a *py.Object) *py.Object
+
+// FuncD__2 is like FuncD__0 but also passes a, b.
+//
+//go:linkname FuncD__2 py.func_d
+func FuncD__2(a *py.Object, b *py.Object) *py.Object
+
+//go:linkname FuncE__0 py.func_e
+func FuncE__0(start *py.Object) *py.Object
+
+// FuncE__1 is like FuncE__0 but also passes unit.
+//
+//go:linkname FuncE__1 py.func_e
+func FuncE__1(start *py.Object, unit *py.Object) *py.Object
@@ -0,0 +1,27 @@
+# demo for keyword arguments
+
+def func_a(a, *, b=1):
+    pass
+
+def func_b(x1, x2, /, out=None, *, where=True, casting='same_kind'):
+    pass
+
+def func_c(a, **kwargs):
+    pass
+
+def func_d(*args, kw=None):
+    pass
+
+def func_e(a, *args):
+    pass
+
+def func_f(a, *args, key):
+    """Sort the items."""
+    pass
+
+class Model:
+    def fit(self, x, y=None, **params):
+        pass
+
+    def score(self, x, *, metric):
+        pass
@@ -0,0 +1,127 @@
+package demo
+
+import (
+	"github.com/goplus/lib/py"
+	_ "unsafe"
+)
+
+const GopPackage = true
+const LLGoPackage = "py.demo"
+
+//go:linkname FuncA py.func_a
+func FuncA(a *py.Object) *py.Object
+
+//go:linkname pyFuncA py.func_a
+var pyFuncA *py.Object
+
+// FuncAKw is like FuncA but also accepts keyword arguments,
+// kw is a dict mapping argument names to values.
+func FuncAKw(a *py.Object, kw *py.Object) *py.Object {
+	return pyFuncA.Call(py.Tuple(a), kw)
+}
+
+//go:linkname FuncB__0 py.func_b
+func FuncB__0(x1 *py.Object, x2 *py.Object) *py.Object
+
+// FuncB__1 is like FuncB__0 but also passes out.
+//
+//go:linkname FuncB__1 py.func_b
+func FuncB__1(x1 *py.Object, x2 *py.Object, out *py.Object) *py.Object
+
+//go:linkname pyFuncB py.func_b
+var pyFuncB *py.Object
+
+// FuncBKw__0 is like FuncB__0 but also accepts keyword arguments,
+// kw is a dict mapping argument names to values.
+func FuncBKw__0(x1 *py.Object, x2 *py.Object, kw *py.Object) *py.Object {
+	return pyFuncB.Call(py.Tuple(x1, x2), kw)
+}
+
+// FuncBKw__1 is like FuncB__1 but also accepts keyword arguments,
+// kw is a dict mapping argument names to values.
+func FuncBKw__1(x1 *py.Object, x2 *py.Object, out *py.Object, kw *py.Object) *py.Object {
+	return pyFuncB.Call(py.Tuple(x1, x2, out), kw)
+}
+
+//go:linkname FuncC py.func_c
+func FuncC(a *py.Object) *py.Object
+
+//go:linkname pyFuncC py.func_c
+var pyFuncC *py.Object
+
+// FuncCKw is like FuncC but also accepts keyword arguments,
+// kw is a dict mapping argument names to values.
+func FuncCKw(a *py.Object, kw *py.Object) *py.Object {
+	return pyFuncC.Call(py.Tuple(a), kw)
+}
+
+//go:linkname FuncD py.func_d
+func FuncD(__llgo_va_list ...interface{}) *py.Object
+
+//go:linkname pyFuncD py.func_d
+var pyFuncD *py.Object
+
+// FuncDKw is like FuncD but also accepts keyword arguments,
+// kw is a dict mapping argument names to values.
+// The args are passed to *args after the other positional arguments.
+func FuncDKw(kw *py.Object, args ...*py.Object) *py.Object {
+	list := py.NewList(0)
+	for _, arg := range args {
+		list.ListAppend(arg)
+	}
+	return pyFuncD.Call(list.ListAsTuple(), kw)
+}
+
+//go:linkname FuncE py.func_e
+func FuncE(a *py.Object, __llgo_va_list ...interface{}) *py.Object
+
+//go:linkname pyFuncF py.func_f
+var pyFuncF *py.Object
+
+// Sort the items.
+//
+// FuncFKw calls func_f with keyword arguments, kw is a dict
+// mapping argument names to values, it must hold key.
+// The args are passed to *args after the other positional arguments.
+func FuncFKw(a *py.Object, kw *py.Object, args ...*py.Object) *py.Object {
+	list := py.NewList(0)
+	list.ListAppend(a)
+	for _, arg := range args {
+		list.ListAppend(arg)
+	}
+	return pyFuncF.Call(list.ListAsTuple(), kw)
+}
+
+type Model struct {
+	py.Object
+}
+
+//llgo:link (*Model).Fit__0 py.Model.fit
+func (m *Model) Fit__0(x *py.Object) *py.Object {
+	return nil
+}
+
+// Fit__1 is like Fit__0 but also passes y.
+//
+//llgo:link (*Model).Fit__1 py.Model.fit
+func (m *Model) Fit__1(x *py.Object, y *py.Object) *py.Object {
+	return nil
+}
+
+// FitKw__0 is like Fit__0 but also accepts keyword arguments,
+// kw is a dict mapping argument names to values.
+func (m *Model) FitKw__0(x *py.Object, kw *py.Object) *py.Object {
+	return m.Object.GetAttr(py.Str("fit")).Call(py.Tuple(x), kw)
+}
+
+// FitKw__1 is like Fit__1 but also accepts keyword arguments,
+// kw is a dict mapping argument names to values.
+func (m *Model) FitKw__1(x *py.Object, y *py.Object, kw *py.Object) *py.Object {
+	return m.Object.GetAttr(py.Str("fit")).Call(py.Tuple(x, y), kw)
+}
+
+// ScoreKw calls score with keyword arguments, kw is a dict
+// mapping argument names to values, it must hold metric.
+func (m *Model) ScoreKw(x *py.Object, kw *py.Object) *py.Object {
+	return m.Object.GetAttr(py.Str("score")).Call(py.Tuple(x), kw)
+}
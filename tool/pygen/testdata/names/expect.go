@@ -0,0 +1,69 @@
+package demo
+
+import (
+	"github.com/goplus/lib/py"
+	_ "unsafe"
+)
+
+const GopPackage = true
+const LLGoPackage = "py.demo"
+
+// pi = 3.14
+//
+//go:linkname Pi py.pi
+var Pi *py.Object
+
+// Pi = 3.1416
+//
+//go:linkname Pi_2 py.Pi
+var Pi_2 *py.Object
+
+// Sum of a, see [Sum_2].
+//
+//go:linkname Sum py.sum
+func Sum(a *py.Object) *py.Object
+
+//go:linkname Sum_2 py.Sum
+func Sum_2(a *py.Object, b *py.Object) *py.Object
+
+//go:linkname SumKw_2 py.sum_kw
+func SumKw_2(a *py.Object) *py.Object
+
+//go:linkname FuncA py.func_a
+func FuncA() *py.Object
+
+//go:linkname FuncA_2 py.funcA
+func FuncA_2() *py.Object
+
+//go:linkname FuncA_3 py.FuncA
+func FuncA_3() *py.Object
+
+//go:linkname LLGoPackage_2 py.LLGoPackage
+func LLGoPackage_2() *py.Object
+
+//go:linkname Array__0 py.array
+func Array__0(a *py.Object) *py.Object
+
+// Array__1 is like Array__0 but also passes b.
+//
+//go:linkname Array__1 py.array
+func Array__1(a *py.Object, b *py.Object) *py.Object
+
+type Array_2 struct {
+	py.Object
+}
+
+//llgo:link (*Array_2).Size py.Array.size
+func (a *Array_2) Size() *py.Object {
+	return nil
+}
+
+//llgo:link (*Array_2).Size_2 py.Array.Size
+func (a *Array_2) Size_2() *py.Object {
+	return nil
+}
+
+//llgo:link (*Array_2).Object_2 py.Array.object
+func (a *Array_2) Object_2() *py.Object {
+	return nil
+}
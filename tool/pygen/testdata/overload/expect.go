@@ -0,0 +1,110 @@
+package demo
+
+import (
+	"github.com/goplus/lib/py"
+	_ "unsafe"
+)
+
+const GopPackage = true
+const LLGoPackage = "py.demo"
+
+// range(stop) -> range object
+// range(start, stop[, step]) -> range object
+//
+// Return an object that produces a sequence of integers.
+//
+//go:linkname Range__0 py.range
+func Range__0(stop *py.Object) *py.Object
+
+// Range__1 is like Range__0 but calls range(start, stop).
+//
+//go:linkname Range__1 py.range
+func Range__1(start *py.Object, stop *py.Object) *py.Object
+
+// Range__2 is like Range__0 but calls range(start, stop, step).
+//
+//go:linkname Range__2 py.range
+func Range__2(start *py.Object, stop *py.Object, step *py.Object) *py.Object
+
+// arange([start,] stop[, step,], dtype=None, *, like=None)
+//
+// Return evenly spaced values within a given interval.
+//
+//go:linkname Arange__0 py.arange
+func Arange__0(stop *py.Object) *py.Object
+
+// Arange__1 is like Arange__0 but calls arange(start, stop).
+//
+//go:linkname Arange__1 py.arange
+func Arange__1(start *py.Object, stop *py.Object) *py.Object
+
+// Arange__2 is like Arange__0 but calls arange(start, stop, step).
+//
+//go:linkname Arange__2 py.arange
+func Arange__2(start *py.Object, stop *py.Object, step *py.Object) *py.Object
+
+// Arange__3 is like Arange__0 but calls arange(start, stop, step, dtype).
+//
+//go:linkname Arange__3 py.arange
+func Arange__3(start *py.Object, stop *py.Object, step *py.Object, dtype *py.Object) *py.Object
+
+//go:linkname pyArange py.arange
+var pyArange *py.Object
+
+// ArangeKw__0 is like Arange__0 but also accepts keyword arguments,
+// kw is a dict mapping argument names to values.
+func ArangeKw__0(stop *py.Object, kw *py.Object) *py.Object {
+	return pyArange.Call(py.Tuple(stop), kw)
+}
+
+// ArangeKw__1 is like Arange__1 but also accepts keyword arguments,
+// kw is a dict mapping argument names to values.
+func ArangeKw__1(start *py.Object, stop *py.Object, kw *py.Object) *py.Object {
+	return pyArange.Call(py.Tuple(start, stop), kw)
+}
+
+// ArangeKw__2 is like Arange__2 but also accepts keyword arguments,
+// kw is a dict mapping argument names to values.
+func ArangeKw__2(start *py.Object, stop *py.Object, step *py.Object, kw *py.Object) *py.Object {
+	return pyArange.Call(py.Tuple(start, stop, step), kw)
+}
+
+// ArangeKw__3 is like Arange__3 but also accepts keyword arguments,
+// kw is a dict mapping argument names to values.
+func ArangeKw__3(start *py.Object, stop *py.Object, step *py.Object, dtype *py.Object, kw *py.Object) *py.Object {
+	return pyArange.Call(py.Tuple(start, stop, step, dtype), kw)
+}
+
+// Parse a value.
+//
+// Returns: int
+//
+//go:linkname Parse__0 py.parse
+func Parse__0(s *py.Object) *py.Object
+
+// Parse__1 is like Parse__0 but also passes base.
+//
+//go:linkname Parse__1 py.parse
+func Parse__1(s *py.Object, base *py.Object) *py.Object
+
+// A timer.
+type Timer struct {
+	py.Object
+}
+
+// start(self)
+// start(self, delay, repeat)
+//
+// Start the timer.
+//
+//llgo:link (*Timer).Start__0 py.Timer.start
+func (t *Timer) Start__0() *py.Object {
+	return nil
+}
+
+// Start__1 is like Start__0 but also passes delay, repeat.
+//
+//llgo:link (*Timer).Start__1 py.Timer.start
+func (t *Timer) Start__1(delay *py.Object, repeat *py.Object) *py.Object {
+	return nil
+}
@@ -0,0 +1,243 @@
+package demo
+
+import (
+	"errors"
+	"github.com/goplus/lib/c"
+	"github.com/goplus/lib/py"
+	_ "unsafe"
+)
+
+const GopPackage = true
+const LLGoPackage = "py.demo"
+
+// Returns: str
+//
+//go:linkname FuncC py.func_c
+func FuncC(a *py.Object, b *py.Object) *py.Object
+
+// FuncCTyped is like FuncC but with go types, it converts the arguments
+// and the result, and returns an error if func_c raises an exception,
+// or returns a value that is not a str.
+func FuncCTyped(a int64, b float64) (string, error) {
+	ret := FuncC(py.LongLong(a), py.Float(b))
+	if ret == nil {
+		py.ErrClear()
+		return "", errors.New("demo.func_c raised an exception")
+	}
+	typ := ret.Type()
+	ok := typ.TypeFlags()&(1<<28) != 0
+	typ.DecRef()
+	if !ok {
+		return "", errors.New("demo.func_c returned a value that is not a str")
+	}
+	return c.GoString(ret.CStr()), nil
+}
+
+// Returns: bool
+//
+//go:linkname IsEven py.is_even
+func IsEven(n *py.Object) *py.Object
+
+// IsEvenTyped is like IsEven but with go types, it converts the arguments
+// and the result, and returns an error if is_even raises an exception.
+func IsEvenTyped(n int64) (bool, error) {
+	ret := IsEven(py.LongLong(n))
+	if ret == nil {
+		py.ErrClear()
+		return false, errors.New("demo.is_even raised an exception")
+	}
+	b := ret.IsTrue()
+	if b == -1 {
+		py.ErrClear()
+		return false, errors.New("demo.is_even raised an exception converting the result to a bool")
+	}
+	return b != 0, nil
+}
+
+// Returns: float
+//
+//go:linkname Total__0 py.total
+func Total__0(values *py.Object) *py.Object
+
+// Total__1 is like Total__0 but also passes scale.
+//
+//go:linkname Total__1 py.total
+func Total__1(values *py.Object, scale *py.Object) *py.Object
+
+// TotalTyped is like Total__1 but with go types, it converts the arguments
+// and the result, and returns an error if total raises an exception,
+// or returns a value that is not a float.
+func TotalTyped(values []float64, scale float64) (float64, error) {
+	values_ := py.NewList(len(values))
+	for i, item := range values {
+		values_.ListSetItem(i, py.Float(item))
+	}
+	ret := Total__1(values_, py.Float(scale))
+	if ret == nil {
+		py.ErrClear()
+		return 0, errors.New("demo.total raised an exception")
+	}
+	typ := ret.Type()
+	zero := py.Float(0)
+	floatType := zero.Type()
+	ok := typ == floatType || typ.TypeFlags()&(1<<24) != 0
+	typ.DecRef()
+	floatType.DecRef()
+	zero.DecRef()
+	if !ok {
+		return 0, errors.New("demo.total returned a value that is not a float")
+	}
+	f := ret.Float64()
+	if f == -1 && pyErr_Occurred() != nil {
+		py.ErrClear()
+		return 0, errors.New("demo.total raised an exception converting the result to a float")
+	}
+	return f, nil
+}
+
+//go:linkname pyErr_Occurred C.PyErr_Occurred
+func pyErr_Occurred() *py.Object
+
+// Returns: list[str]
+//
+//go:linkname Split py.split
+func Split(s *py.Object, sep *py.Object) *py.Object
+
+// SplitTyped is like Split but with go types, it converts the arguments
+// and the result, and returns an error if split raises an exception,
+// or returns a value that is not a list[str].
+func SplitTyped(s string, sep string) ([]string, error) {
+	ret := Split(py.Str(s), py.Str(sep))
+	if ret == nil {
+		py.ErrClear()
+		return nil, errors.New("demo.split raised an exception")
+	}
+	typ := ret.Type()
+	ok := typ.TypeFlags()&(1<<25) != 0
+	typ.DecRef()
+	if !ok {
+		return nil, errors.New("demo.split returned a value that is not a list[str]")
+	}
+	list := make([]string, ret.ListLen())
+	for i := range list {
+		item := ret.ListItem(i)
+		typ_ := item.Type()
+		ok_ := typ_.TypeFlags()&(1<<28) != 0
+		typ_.DecRef()
+		if !ok_ {
+			return nil, errors.New("demo.split returned a list with an item that is not a str")
+		}
+		list[i] = c.GoString(item.CStr())
+	}
+	return list, nil
+}
+
+// Returns: None
+//
+//go:linkname Log py.log
+func Log(msg *py.Object) *py.Object
+
+//go:linkname pyLog py.log
+var pyLog *py.Object
+
+// LogKw is like Log but also accepts keyword arguments,
+// kw is a dict mapping argument names to values.
+func LogKw(msg *py.Object, kw *py.Object) *py.Object {
+	return pyLog.Call(py.Tuple(msg), kw)
+}
+
+// LogTyped is like Log but with go types, it converts the arguments
+// and the result, and returns an error if log raises an exception.
+func LogTyped(msg string) error {
+	ret := Log(py.Str(msg))
+	if ret == nil {
+		py.ErrClear()
+		return errors.New("demo.log raised an exception")
+	}
+	return nil
+}
+
+//go:linkname Load py.load
+func Load(path *py.Object) *py.Object
+
+// LoadTyped is like Load but with go types, it converts the arguments
+// and the result, and returns an error if load raises an exception.
+func LoadTyped(path string) (*py.Object, error) {
+	ret := Load(py.Str(path))
+	if ret == nil {
+		py.ErrClear()
+		return nil, errors.New("demo.load raised an exception")
+	}
+	return ret, nil
+}
+
+// Returns: int
+//
+//go:linkname Flag py.flag
+func Flag(on *py.Object) *py.Object
+
+// FlagTyped is like Flag but with go types, it converts the arguments
+// and the result, and returns an error if flag raises an exception,
+// or returns a value that is not an int.
+func FlagTyped(on bool) (int64, error) {
+	var on_ c.Long
+	if on {
+		on_ = 1
+	}
+	ret := Flag(pyBool_FromLong(on_))
+	if ret == nil {
+		py.ErrClear()
+		return 0, errors.New("demo.flag raised an exception")
+	}
+	typ := ret.Type()
+	ok := typ.TypeFlags()&(1<<24) != 0
+	typ.DecRef()
+	if !ok {
+		return 0, errors.New("demo.flag returned a value that is not an int")
+	}
+	n := ret.LongLong()
+	if n == -1 && pyErr_Occurred() != nil {
+		py.ErrClear()
+		return 0, errors.New("demo.flag raised an exception converting the result to an int")
+	}
+	return n, nil
+}
+
+//go:linkname pyBool_FromLong C.PyBool_FromLong
+func pyBool_FromLong(v c.Long) *py.Object
+
+//go:linkname FuncA py.func_a
+func FuncA() *py.Object
+
+// Returns: list[bool]
+//
+//go:linkname Flags py.flags
+func Flags(n *py.Object) *py.Object
+
+// FlagsTyped is like Flags but with go types, it converts the arguments
+// and the result, and returns an error if flags raises an exception,
+// or returns a value that is not a list[bool].
+func FlagsTyped(n int64) ([]bool, error) {
+	ret := Flags(py.LongLong(n))
+	if ret == nil {
+		py.ErrClear()
+		return nil, errors.New("demo.flags raised an exception")
+	}
+	typ := ret.Type()
+	ok := typ.TypeFlags()&(1<<25) != 0
+	typ.DecRef()
+	if !ok {
+		return nil, errors.New("demo.flags returned a value that is not a list[bool]")
+	}
+	list := make([]bool, ret.ListLen())
+	for i := range list {
+		item := ret.ListItem(i)
+		b := item.IsTrue()
+		if b == -1 {
+			py.ErrClear()
+			return nil, errors.New("demo.flags raised an exception converting the result to a bool")
+		}
+		list[i] = b != 0
+	}
+	return list, nil
+}
@@ -0,0 +1,11 @@
+# demo for variable
+
+import math
+
+pi = 3.141592653589793
+max_size = 9223372036854775807
+name = 'demo'
+enabled = True
+nothing = None
+items = [1, 2, 3]
+_private = 1
@@ -0,0 +1,36 @@
+package demo
+
+import (
+	"github.com/goplus/lib/py"
+	_ "unsafe"
+)
+
+const LLGoPackage = "py.demo"
+
+// pi = 3.141592653589793
+//
+//go:linkname Pi py.pi
+var Pi *py.Object
+
+// max_size = 9223372036854775807
+//
+//go:linkname MaxSize py.max_size
+var MaxSize *py.Object
+
+// name = 'demo'
+//
+//go:linkname Name py.name
+var Name *py.Object
+
+// enabled = True
+//
+//go:linkname Enabled py.enabled
+var Enabled *py.Object
+
+// nothing = None
+//
+//go:linkname Nothing py.nothing
+var Nothing *py.Object
+
+//go:linkname Items py.items
+var Items *py.Object
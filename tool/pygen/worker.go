@@ -0,0 +1,112 @@
+package pygen
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+	"github.com/goplus/llpyg/symbol"
+)
+
+// WorkerDumper dumps all modules with one long-running pydump worker,
+// so that every package is imported once per run. It speaks the
+// line-delimited JSON protocol of symbol.DumpRequest:
+//
+//	-> {"module":"numpy.fft"}
+//	<- {"module":"numpy.fft","result":{...}}
+//
+// A worker that crashes, e.g. in a C extension, or runs out of Timeout is
+// stopped, the module is reported as a *DumpError, and the next Dump restarts it.
+type WorkerDumper struct {
+	Path    string        // worker executable, "pydump" if empty
+	Args    []string      // arguments starting the worker, ["-worker"] if nil
+	Timeout time.Duration // timeout of each module, no timeout if 0
+
+	cmd    *exec.Cmd
+	in     io.WriteCloser
+	out    *bufio.Reader
+	stderr *tailWriter
+}
+
+func (d *WorkerDumper) Dump(moduleName string) (mod symbol.Module, err error) {
+	if d.cmd == nil {
+		if err = d.start(); err != nil {
+			return mod, fmt.Errorf("start pydump worker failed: %w", err)
+		}
+	}
+	req, err := json.Marshal(&symbol.DumpRequest{Module: moduleName})
+	if err != nil {
+		return mod, err
+	}
+	d.stderr.Reset()
+	timer := killAfter(d.cmd, d.Timeout)
+	_, err = d.in.Write(append(req, '\n'))
+	var line []byte
+	if err == nil {
+		line, err = d.out.ReadBytes('\n')
+	}
+	timer.Stop()
+	if err != nil {
+		// the worker crashed or was killed
+		if waitErr := d.stop(); waitErr != nil {
+			err = waitErr
+		}
+		return mod, newDumpError(moduleName, err, timer, d.stderr)
+	}
+	var resp symbol.DumpResponse
+	err = json.Unmarshal(line, &resp)
+	if err != nil {
+		return mod, fmt.Errorf("unmarshal %s failed: %w", moduleName, err)
+	}
+	if resp.Error != "" {
+		return mod, &DumpError{Module: moduleName, Reason: resp.Error, Stderr: d.stderr.Tail()}
+	}
+	if resp.Result == nil || resp.Result.Name != moduleName {
+		return mod, fmt.Errorf("import module failed: %s", moduleName)
+	}
+	return *resp.Result, nil
+}
+
+// Close stops the worker.
+func (d *WorkerDumper) Close() error {
+	if d.cmd == nil {
+		return nil
+	}
+	return d.stop()
+}
+
+func (d *WorkerDumper) start() (err error) {
+	path, args := d.Path, d.Args
+	if path == "" {
+		path = "pydump"
+	}
+	if args == nil {
+		args = []string{"-worker"}
+	}
+	cmd := exec.Command(path, args...)
+	d.stderr = &tailWriter{w: os.Stderr}
+	cmd.Stderr = d.stderr
+	if d.in, err = cmd.StdinPipe(); err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err = cmd.Start(); err != nil {
+		return err
+	}
+	d.cmd, d.out = cmd, bufio.NewReader(stdout)
+	return nil
+}
+
+// closing stdin ends the worker
+func (d *WorkerDumper) stop() error {
+	d.in.Close()
+	err := d.cmd.Wait()
+	d.cmd, d.in, d.out = nil, nil, nil
+	return err
+}
@@ -0,0 +1,260 @@
+# pydump without LLGo: prints the symbol.Module JSON of a python module.
+# Keep in sync with _xtool/pydump.
+
+import contextlib
+import importlib
+import inspect
+import json
+import os
+import re
+import sys
+
+PY_FUNC_TYPES = {
+    "ufunc",
+    "method",
+    "function",
+    "method-wrapper",
+    "builtin_function_or_method",
+    "_ArrayFunctionDispatcher",
+}
+
+# types of class members found in the class __dict__
+PY_METHOD_TYPES = {"function", "method_descriptor", "cython_function_or_method"}
+PY_CLASS_METHOD_TYPES = {"classmethod", "classmethod_descriptor"}
+PY_STATIC_METHOD_TYPES = {"staticmethod", "builtin_function_or_method"}
+
+# types of variables whose repr is a python literal
+PY_LITERAL_TYPES = {"int", "float", "complex", "bool", "str", "bytes", "NoneType"}
+
+
+def is_method_type(typ):
+    return typ in PY_METHOD_TYPES or typ in PY_CLASS_METHOD_TYPES or typ in PY_STATIC_METHOD_TYPES
+
+
+def new_symbol(name, typ):
+    return {"name": name, "type": typ, "doc": "", "sig": "", "params": None}
+
+
+def get_doc(val):
+    doc = getattr(val, "__doc__", None)
+    return str(doc) if doc else ""
+
+
+# signatures of the leading lines of doc, one per call form, e.g.
+# range(stop) -> range object
+# range(start, stop[, step]) -> range object
+def extract_signatures_from_doc(doc, func_name):
+    start = re.compile(r"(?:\w+\.)*" + re.escape(func_name) + r"\(")
+    lines = doc.lstrip("\n").split("\n")
+    sigs = []
+    i = 0
+    while i < len(lines):
+        line = lines[i].strip()
+        m = start.match(line)
+        if m is None:
+            if sigs and lines[i][:1] in (" ", "\t"):
+                i += 1  # indented description of the previous call form
+                continue
+            break
+        # a long signature may wrap over lines
+        while bracket_depth(line) > 0 and i + 1 < len(lines) and lines[i + 1].strip():
+            i += 1
+            line += " " + lines[i].strip()
+        sigs.append(" ".join(line[m.end() - 1:].split()))
+        i += 1
+    return sigs
+
+
+def bracket_depth(s):
+    return sum(s.count(c) for c in "([") - sum(s.count(c) for c in ")]")
+
+
+# structured parameters and return annotation of an inspect.Signature
+def get_params(sig):
+    empty = inspect.Parameter.empty
+
+    def format(annotation):
+        return "" if annotation is empty else inspect.formatannotation(annotation)
+
+    params = []
+    for p in sig.parameters.values():
+        param = {"name": p.name, "kind": p.kind.name}
+        annotation = format(p.annotation)
+        if annotation:
+            param["annotation"] = annotation
+        if p.default is not empty:
+            param["default"] = repr(p.default)
+        params.append(param)
+    return params, format(sig.return_annotation)
+
+
+def get_signature(val, sym):
+    # function, method, class, or implement __call__
+    if not callable(val):
+        return ""
+    sigs = extract_signatures_from_doc(sym["doc"], sym["name"])
+    if sigs:
+        sym["sigs"] = sigs
+    # get signature from inspect
+    try:
+        sig = inspect.signature(val)
+    except (TypeError, ValueError):
+        sig = None
+    if sig is not None and str(sig) != "(*args, **kwargs)":
+        sym["params"], ret = get_params(sig)
+        if ret:
+            sym["return"] = ret
+        return str(sig)
+    # get signature from doc
+    if sigs:
+        return sigs[0]
+    # Paradigms
+    if sym["type"] in PY_FUNC_TYPES or is_method_type(sym["type"]):
+        return "(*args, **kwargs)"
+    return ""
+
+
+# full name of a class, e.g. builtins.object
+def get_class_name(cls):
+    qualname = getattr(cls, "__qualname__", None)
+    if qualname is None:
+        return ""
+    mod = getattr(cls, "__module__", None)
+    if not mod:
+        return str(qualname)
+    return str(mod) + "." + str(qualname)
+
+
+def append(obj, key, item):
+    if obj[key] is None:
+        obj[key] = []
+    obj[key].append(item)
+
+
+def dump_class(cls, name):
+    cls_instance = {
+        "name": name,
+        "doc": get_doc(cls),
+        "bases": None,
+        "methods": None,
+        "classMethods": None,
+        "staticMethods": None,
+    }
+    # base classes
+    for base in getattr(cls, "__bases__", ()):
+        base_name = get_class_name(base)
+        if base_name:
+            append(cls_instance, "bases", base_name)
+    # members defined by the class itself, inherited ones belong to the bases
+    members = getattr(cls, "__dict__", {})
+    for key in list(members):
+        sym = new_symbol(key, type(members[key]).__name__)
+        if not is_method_type(sym["type"]):
+            continue
+        # attribute lookup unwraps classmethod and staticmethod objects
+        try:
+            val = getattr(cls, key)
+        except Exception:
+            continue
+        sym["doc"] = get_doc(val)
+        sym["sig"] = get_signature(val, sym)
+        if sym["type"] in PY_METHOD_TYPES:
+            append(cls_instance, "methods", sym)
+        elif sym["type"] in PY_CLASS_METHOD_TYPES:
+            append(cls_instance, "classMethods", sym)
+        else:
+            append(cls_instance, "staticMethods", sym)
+    return cls_instance
+
+
+def pydump(module_name):
+    # libraries printing banners at import must not corrupt the output
+    with contextlib.redirect_stdout(sys.stderr):
+        return dump_module(module_name)
+
+
+def dump_module(module_name):
+    mod = importlib.import_module(module_name)
+    mod_instance = {
+        "name": module_name,
+        "functions": None,
+        "classes": None,
+        "variables": None,
+    }
+    file = getattr(mod, "__file__", None)
+    if isinstance(file, str):  # None for namespace packages
+        mod_instance["file"] = file
+    for key in list(vars(mod)):
+        try:
+            val = getattr(mod, key)
+        except Exception:
+            continue
+        # classes
+        if inspect.isclass(val):
+            append(mod_instance, "classes", dump_class(val, key))
+            continue
+        sym = new_symbol(key, type(val).__name__)
+        # functions
+        if sym["type"] in PY_FUNC_TYPES:
+            sym["doc"] = get_doc(val)
+            sym["sig"] = get_signature(val, sym)
+            append(mod_instance, "functions", sym)
+            continue
+        # variables, submodules are not
+        if not callable(val) and not inspect.ismodule(val):
+            if sym["type"] in PY_LITERAL_TYPES:
+                sym["repr"] = repr(val)
+            append(mod_instance, "variables", sym)
+    return mod_instance
+
+
+# the output channel: the file if given, or else the original stdout,
+# with fd 1 redirected to stderr for prints of C extensions
+def open_output(path):
+    if path:
+        return open(path, "w")
+    sys.stdout.flush()
+    out = os.fdopen(os.dup(1), "w")
+    os.dup2(2, 1)
+    return out
+
+
+# long-running mode, dumps the modules requested on stdin
+# with the line-delimited JSON protocol of symbol.DumpRequest
+def serve(out):
+    for line in sys.stdin:
+        if not line.strip():
+            continue
+        resp = {"module": ""}
+        try:
+            resp["module"] = json.loads(line)["module"]
+            resp["result"] = pydump(resp["module"])
+        except Exception as e:
+            resp["error"] = "failed to import module %s: %s" % (resp["module"], e)
+        out.write(json.dumps(resp) + "\n")
+        out.flush()
+
+
+def main():
+    args = sys.argv[1:]
+    path = ""
+    if len(args) > 1 and args[0] == "-o":
+        path, args = args[1], args[2:]
+    if args and args[0] == "-worker":
+        serve(open_output(path))
+        return
+    if not args:
+        print("Usage: pydump [-o <file>] [-worker] <py_module_name>")
+        return
+    module_name = args[0]
+    out = open_output(path)
+    try:
+        mod = pydump(module_name)
+    except Exception as e:
+        print("failed to import module %s: %s" % (module_name, e), file=sys.stderr)
+        sys.exit(1)
+    out.write(json.dumps(mod, indent=2) + "\n")
+    out.close()
+
+
+main()
@@ -0,0 +1,90 @@
+// Package pyinspect is the pure CPython backend of pydump and pymodule.
+// It runs embedded introspection scripts with the python3 interpreter,
+// so it needs neither LLGo nor the LLGo-built tools.
+package pyinspect
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"github.com/goplus/llpyg/symbol"
+	"github.com/goplus/llpyg/tool/pygen"
+)
+
+//go:embed pydump.py
+var pydumpScript string
+
+//go:embed pymodule.py
+var pymoduleScript string
+
+// Python returns the python interpreter to use,
+// $PYTHONHOME/bin/python3 if PYTHONHOME is set, or python3 in PATH.
+func Python() string {
+	if pyHome := os.Getenv("PYTHONHOME"); pyHome != "" {
+		return filepath.Join(pyHome, "bin", "python3")
+	}
+	return "python3"
+}
+
+// Dumper gets module symbols by running the embedded pydump script.
+type Dumper struct {
+	Python string // python interpreter, Python() if empty
+}
+
+func (d *Dumper) Dump(moduleName string) (mod symbol.Module, err error) {
+	out, err := run(d.Python, pydumpScript, moduleName)
+	if err != nil {
+		return mod, fmt.Errorf("pydump %s failed: %w", moduleName, err)
+	}
+	err = json.Unmarshal(out, &mod)
+	if err != nil {
+		return mod, fmt.Errorf("unmarshal %s failed: %w", moduleName, err)
+	}
+	if mod.Name != moduleName {
+		return mod, fmt.Errorf("import module failed: %s", moduleName)
+	}
+	return mod, nil
+}
+
+// NewWorkerDumper returns a dumper that runs the embedded pydump script as
+// one long-running worker, see pygen.WorkerDumper.
+func NewWorkerDumper(python string) *pygen.WorkerDumper {
+	if python == "" {
+		python = Python()
+	}
+	return &pygen.WorkerDumper{Path: python, Args: []string{"-c", pydumpScript, "-worker"}}
+}
+
+// Modules runs the embedded pymodule script, and returns the library JSON
+// with the modules of libName up to depth. Each module is imported in a child
+// process with a timeout in seconds, failed ones are listed in the JSON.
+func Modules(python, libName string, depth, timeout int) ([]byte, error) {
+	out, err := run(python, pymoduleScript, "-d", strconv.Itoa(depth), "-timeout", strconv.Itoa(timeout), libName)
+	if err != nil {
+		return nil, fmt.Errorf("get modules from %s failed: %w", libName, err)
+	}
+	return out, nil
+}
+
+// python -c script args...
+func run(python, script string, args ...string) ([]byte, error) {
+	if python == "" {
+		python = Python()
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(python, append([]string{"-c", script}, args...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			return nil, fmt.Errorf("%w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,194 @@
+package pyinspect
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"github.com/goplus/llpyg/symbol"
+	"github.com/goplus/llpyg/tool/pygen"
+)
+
+func checkPython(t *testing.T, pythonPath string) {
+	if _, err := exec.LookPath(Python()); err != nil {
+		t.Skipf("python not found: %v", err)
+	}
+	t.Setenv("PYTHONPATH", pythonPath)
+	t.Setenv("PYTHONDONTWRITEBYTECODE", "1")
+}
+
+// same output as pydump
+func TestDump(t *testing.T) {
+	for _, dir := range []string{"func", "class", "var", "kwargs"} {
+		testdata := "../pygen/testdata/" + dir
+		checkPython(t, testdata)
+		mod, err := (&Dumper{}).Dump("demo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(testdata + "/demo.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var want symbol.Module
+		if err = json.Unmarshal(data, &want); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(mod.Functions, want.Functions) {
+			t.Fatalf("%s: functions not match pydump", dir)
+		}
+		if !reflect.DeepEqual(mod.Classes, want.Classes) {
+			t.Fatalf("%s: classes not match pydump", dir)
+		}
+		if len(mod.Variables) != len(want.Variables) {
+			t.Fatalf("%s: len(variables) = %d, want %d", dir, len(mod.Variables), len(want.Variables))
+		}
+	}
+}
+
+// prints at import go to stderr
+func TestDumpNoisy(t *testing.T) {
+	checkPython(t, "./testdata")
+	mod, err := (&Dumper{}).Dump("demolib.noisy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mod.Functions) != 1 || mod.Functions[0].Name != "hello" {
+		t.Fatalf("Dump(demolib.noisy) = %+v", mod)
+	}
+}
+
+// builtins without inspect signatures document several call forms
+func TestDumpDocSignatures(t *testing.T) {
+	checkPython(t, "./testdata")
+	mod, err := (&Dumper{}).Dump("builtins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"iter": {"(iterable) -> iterator", "(callable, sentinel) -> iterator"},
+		"min":  {"(iterable, *[, default=obj, key=func]) -> value", "(arg1, arg2, *args, *[, key=func]) -> value"},
+	}
+	for _, sym := range mod.Functions {
+		if sigs, ok := want[sym.Name]; ok {
+			if !reflect.DeepEqual(sym.Sigs, sigs) || sym.Sig != sigs[0] {
+				t.Fatalf("%s: sig = %q, sigs = %q, want %q", sym.Name, sym.Sig, sym.Sigs, sigs)
+			}
+			delete(want, sym.Name)
+		}
+	}
+	if len(want) > 0 {
+		t.Fatalf("functions not found: %v", want)
+	}
+}
+
+func TestWorkerDumper(t *testing.T) {
+	checkPython(t, "./testdata")
+	dumper := NewWorkerDumper("")
+	defer dumper.Close()
+	for _, name := range []string{"demolib", "demolib.util", "nonexistent", "demolib.noisy", "demolib.sub"} {
+		mod, err := dumper.Dump(name)
+		if name == "nonexistent" {
+			if err == nil {
+				t.Fatalf("Dump(%s) should fail", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mod.Name != name {
+			t.Fatalf("Dump(%s).Name = %s", name, mod.Name)
+		}
+		if name == "demolib.util" && (len(mod.Functions) != 1 || mod.Functions[0].Name != "hello") {
+			t.Fatalf("Dump(%s).Functions = %v", name, mod.Functions)
+		}
+	}
+}
+
+func TestWorkerDumperIsolation(t *testing.T) {
+	checkPython(t, "./testdata")
+	dumper := NewWorkerDumper("")
+	dumper.Timeout = 2 * time.Second
+	defer dumper.Close()
+	reasons := map[string]string{
+		"demolib.crash":  "signal: segmentation fault",
+		"demolib.hang":   "timeout after 2s",
+		"demolib.broken": "failed to import module demolib.broken: broken on purpose",
+	}
+	for _, name := range []string{"demolib.crash", "demolib.util", "demolib.hang", "demolib.broken", "demolib.util"} {
+		mod, err := dumper.Dump(name)
+		if reason, ok := reasons[name]; ok {
+			var dumpErr *pygen.DumpError
+			if !errors.As(err, &dumpErr) || dumpErr.Reason != reason {
+				t.Fatalf("Dump(%s) error = %v, want reason %q", name, err, reason)
+			}
+			continue
+		}
+		if err != nil || mod.Name != name {
+			t.Fatalf("Dump(%s) = %v, %v", name, mod.Name, err)
+		}
+	}
+}
+
+func TestModules(t *testing.T) {
+	checkPython(t, "./testdata")
+	out, err := Modules("", "demolib", 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lib struct {
+		LibName    string   `json:"libName"`
+		LibVersion string   `json:"libVersion"`
+		Depth      int      `json:"depth"`
+		Modules    []string `json:"modules"`
+		Failed     []struct {
+			Module string `json:"module"`
+			Reason string `json:"reason"`
+			Stderr string `json:"stderr"`
+		} `json:"failed"`
+	}
+	if err = json.Unmarshal(out, &lib); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"demolib", "demolib.noisy", "demolib.sub", "demolib.util"} // demolib.sub.leaf is deeper than 2
+	if lib.LibVersion != "1.0" || !reflect.DeepEqual(lib.Modules, want) {
+		t.Fatalf("Modules = %+v, want %v", lib, want)
+	}
+	reasons := map[string]string{
+		"demolib.broken": "ImportError: broken on purpose",
+		"demolib.crash":  "signal: segmentation fault",
+		"demolib.hang":   "timeout after 2s",
+	}
+	if len(lib.Failed) != len(reasons) {
+		t.Fatalf("Failed = %+v", lib.Failed)
+	}
+	for _, failed := range lib.Failed {
+		if reasons[failed.Module] != failed.Reason {
+			t.Fatalf("failed %s: reason = %q, want %q", failed.Module, failed.Reason, reasons[failed.Module])
+		}
+	}
+}
+
+// no timeout with 0, and an error if no module is imported
+func TestModulesNoTimeout(t *testing.T) {
+	checkPython(t, "./testdata")
+	out, err := Modules("", "demolib.util", 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lib struct {
+		Modules []string `json:"modules"`
+	}
+	if err = json.Unmarshal(out, &lib); err != nil || !reflect.DeepEqual(lib.Modules, []string{"demolib.util"}) {
+		t.Fatalf("Modules = %s, %v", out, err)
+	}
+	_, err = Modules("", "demolib", 0, 0)
+	if err == nil || !strings.Contains(err.Error(), "no module of demolib imported with depth 0") {
+		t.Fatalf("Modules with depth 0 error = %v", err)
+	}
+}
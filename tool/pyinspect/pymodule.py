@@ -0,0 +1,134 @@
+# pymodule without LLGo: prints the library JSON of a python library.
+# Keep in sync with _xtool/pymodule.
+
+import argparse
+import json
+import signal
+import subprocess
+import sys
+
+# Python library name to module name mapping
+LIB_TO_MODULE = {
+    "scikit-learn": "sklearn",
+    "pillow": "PIL",
+}
+
+
+def get_module_name(lib_name):
+    return LIB_TO_MODULE.get(lib_name, lib_name)
+
+
+# child process: import module, print its version and submodules
+PROBE = r"""
+import contextlib, importlib, json, os, pkgutil, sys
+out = os.fdopen(os.dup(1), "w")
+os.dup2(2, 1)
+with contextlib.redirect_stdout(sys.stderr):
+    mod = importlib.import_module(sys.argv[1])
+res = {"version": "", "subModules": []}
+version = getattr(mod, "__version__", None)
+if version is not None:
+    res["version"] = str(version)
+path = getattr(mod, "__path__", None)
+if path is not None:
+    for m in pkgutil.iter_modules(path):
+        res["subModules"].append({"name": m.name, "isPkg": m.ispkg})
+out.write(json.dumps(res))
+out.flush()
+"""
+
+
+def tail(s, n):
+    return "\n".join(s.strip().split("\n")[-n:])
+
+
+# import module in a child process, so that a crashing or hanging import
+# is reported in pkg["failed"] instead of killing the whole run
+def import_module(pkg, module_name):
+    try:
+        proc = subprocess.run(
+            [sys.executable, "-c", PROBE, module_name],
+            capture_output=True,
+            text=True,
+            timeout=pkg["timeout"] or None,  # no timeout if 0
+        )
+    except subprocess.TimeoutExpired as e:
+        stderr = e.stderr.decode(errors="replace") if isinstance(e.stderr, bytes) else e.stderr or ""
+        add_failure(pkg, module_name, "timeout after %ds" % pkg["timeout"], stderr)
+        return None
+    if proc.returncode == 0:
+        try:
+            return json.loads(proc.stdout)
+        except ValueError as e:
+            add_failure(pkg, module_name, str(e), proc.stderr)
+            return None
+    if proc.returncode < 0:
+        reason = "signal: " + signal.strsignal(-proc.returncode).lower()
+    elif proc.stderr.strip():
+        reason = proc.stderr.strip().split("\n")[-1]  # e.g. ModuleNotFoundError: No module named 'x'
+    else:
+        reason = "exit status %d" % proc.returncode
+    add_failure(pkg, module_name, reason, proc.stderr)
+    return None
+
+
+def add_failure(pkg, module_name, reason, stderr):
+    failure = {"module": module_name, "reason": reason}
+    if stderr.strip():
+        failure["stderr"] = tail(stderr, 20)
+    pkg.setdefault("failed", []).append(failure)
+
+
+def get_modules(pkg, module_name, depth):
+    if depth > pkg["depth"]:
+        return
+    mod = import_module(pkg, module_name)
+    if mod is None:
+        return
+    pkg["modules"].append(module_name)
+    if depth == 1:
+        pkg["libVersion"] = mod["version"]
+    if depth == pkg["depth"]:
+        return
+    for sub_module in mod["subModules"]:
+        name = sub_module["name"]
+        if name.startswith("test") or name.startswith("_"):
+            continue
+        sub_module_name = module_name + "." + name
+        if sub_module["isPkg"]:
+            get_modules(pkg, sub_module_name, depth + 1)
+        elif import_module(pkg, sub_module_name) is not None:
+            pkg["modules"].append(sub_module_name)
+
+
+def main():
+    parser = argparse.ArgumentParser(prog="pymodule")
+    parser.add_argument("-d", type=int, default=1, help="extract depth")
+    parser.add_argument("-timeout", type=int, default=120, help="import timeout of each module in seconds, 0 for none")
+    parser.add_argument("-o", default="", help="write the JSON to this file instead of stdout")
+    parser.add_argument("libraryName")
+    args = parser.parse_args()
+    pkg = {
+        "libName": args.libraryName,
+        "libVersion": "",
+        "depth": args.d,
+        "modules": [],
+        "timeout": args.timeout,
+    }
+    get_modules(pkg, get_module_name(args.libraryName), 1)
+    if not pkg["modules"]:
+        if pkg.get("failed"):
+            print("%s is not installed or not found: %s" % (args.libraryName, pkg["failed"][0]["reason"]), file=sys.stderr)
+        else:
+            print("no module of %s imported with depth %d" % (args.libraryName, args.d), file=sys.stderr)
+        sys.exit(1)
+    del pkg["timeout"]
+    data = json.dumps(pkg, indent=2)
+    if args.o:
+        with open(args.o, "w") as f:
+            f.write(data + "\n")
+    else:
+        print(data)
+
+
+main()
@@ -0,0 +1,10 @@
+import os
+
+print("noisy banner")
+os.write(1, b"noisy C-level banner\n")
+
+VERSION = "1.0"
+
+
+def hello():
+    print("hello")
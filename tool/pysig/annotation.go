@@ -0,0 +1,417 @@
+package pysig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Type is a parsed python type annotation.
+type Type interface {
+	String() string
+	typeNode()
+}
+
+// NameType is a plain or dotted type name, e.g. int, None, numpy.ndarray,
+// or ..., or () for the empty tuple of tuple[()].
+type NameType struct {
+	Name string
+}
+
+// GenericType is a subscripted generic, e.g. list[int], Dict[str, Any].
+type GenericType struct {
+	Base Type
+	Args []Type
+}
+
+// UnionType is a PEP 604 union or a typing.Union, e.g. int | None, Union[int, float].
+type UnionType struct {
+	Types []Type
+}
+
+// OptionalType is typing.Optional[Elem].
+type OptionalType struct {
+	Elem Type
+}
+
+// CallableType is Callable[[Params...], Result], or Callable[..., Result] if
+// Params is nil. Both are nil for a bare Callable.
+type CallableType struct {
+	Params []Type
+	Result Type
+}
+
+// LiteralType is Literal[Values...], the values are kept as written, e.g. 'a', -1, Color.RED.
+type LiteralType struct {
+	Values []string
+}
+
+// AnnotatedType is Annotated[Elem, Metadata...], the metadata are kept as written.
+type AnnotatedType struct {
+	Elem     Type
+	Metadata []string
+}
+
+// ForwardRef is a string-quoted annotation, e.g. 'DataFrame', "str | None".
+type ForwardRef struct {
+	Elem Type
+}
+
+func (*NameType) typeNode()      {}
+func (*GenericType) typeNode()   {}
+func (*UnionType) typeNode()     {}
+func (*OptionalType) typeNode()  {}
+func (*CallableType) typeNode()  {}
+func (*LiteralType) typeNode()   {}
+func (*AnnotatedType) typeNode() {}
+func (*ForwardRef) typeNode()    {}
+
+func (t *NameType) String() string { return t.Name }
+
+func (t *GenericType) String() string {
+	return t.Base.String() + "[" + joinTypes(t.Args, ", ") + "]"
+}
+
+func (t *UnionType) String() string { return joinTypes(t.Types, " | ") }
+
+func (t *OptionalType) String() string { return "Optional[" + t.Elem.String() + "]" }
+
+func (t *CallableType) String() string {
+	if t.Result == nil {
+		return "Callable"
+	}
+	params := "..."
+	if t.Params != nil {
+		params = "[" + joinTypes(t.Params, ", ") + "]"
+	}
+	return "Callable[" + params + ", " + t.Result.String() + "]"
+}
+
+func (t *LiteralType) String() string { return "Literal[" + strings.Join(t.Values, ", ") + "]" }
+
+func (t *AnnotatedType) String() string {
+	return "Annotated[" + t.Elem.String() + ", " + strings.Join(t.Metadata, ", ") + "]"
+}
+
+func (t *ForwardRef) String() string { return "'" + t.Elem.String() + "'" }
+
+func joinTypes(list []Type, sep string) string {
+	strs := make([]string, len(list))
+	for i, t := range list {
+		strs[i] = t.String()
+	}
+	return strings.Join(strs, sep)
+}
+
+// ParseError is a syntax error in a type annotation.
+type ParseError struct {
+	Src string
+	Pos int // byte offset in Src
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("parse type %q failed at %d: %s", e.Src, e.Pos, e.Msg)
+}
+
+// ParseType parses a type annotation, e.g. 'str | None', Optional[int],
+// Callable[[int, str], bool].
+func ParseType(src string) (Type, error) {
+	p := &typeParser{src: src}
+	p.next()
+	t := p.parseUnion()
+	if p.err == nil && p.tok != tokEOF {
+		p.errorf("unexpected %s", p.desc())
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return t, nil
+}
+
+const (
+	tokEOF    = iota
+	tokName   // int, numpy.ndarray
+	tokString // 'a', "a"
+	tokNumber // 1, -1.5
+	tokEllipsis
+	tokOp // [ ] ( ) { } , | and other punctuation
+)
+
+type typeParser struct {
+	src string
+	pos int    // offset of the next token
+	tok int    // current token
+	lit string // current token text
+	off int    // offset of the current token
+	err *ParseError
+}
+
+func (p *typeParser) errorf(format string, args ...any) {
+	if p.err == nil {
+		p.err = &ParseError{Src: p.src, Pos: p.off, Msg: fmt.Sprintf(format, args...)}
+	}
+}
+
+func (p *typeParser) desc() string {
+	if p.tok == tokEOF {
+		return "end of annotation"
+	}
+	return "'" + p.lit + "'"
+}
+
+// scan the next token
+func (p *typeParser) next() {
+	src := p.src
+	for p.pos < len(src) && isSpace(src[p.pos]) {
+		p.pos++
+	}
+	p.off = p.pos
+	if p.pos >= len(src) {
+		p.tok, p.lit = tokEOF, ""
+		return
+	}
+	start, c := p.pos, src[p.pos]
+	switch {
+	case isIdentStart(c):
+		p.pos++
+		for p.pos < len(src) && (isIdentPart(src[p.pos]) || src[p.pos] == '.' && p.pos+1 < len(src) && isIdentStart(src[p.pos+1])) {
+			p.pos++
+		}
+		p.tok = tokName
+	case c == '\'' || c == '"':
+		p.pos++
+		for p.pos < len(src) && src[p.pos] != c {
+			if src[p.pos] == '\\' {
+				p.pos++
+			}
+			p.pos++
+		}
+		if p.pos >= len(src) {
+			p.errorf("unterminated string")
+			p.tok, p.lit = tokEOF, ""
+			return
+		}
+		p.pos++
+		p.tok = tokString
+	case strings.HasPrefix(src[p.pos:], "..."):
+		p.pos += 3
+		p.tok = tokEllipsis
+	case isDigit(c) || c == '-' && p.pos+1 < len(src) && isDigit(src[p.pos+1]):
+		p.pos++
+		for p.pos < len(src) && (isIdentPart(src[p.pos]) || src[p.pos] == '.') {
+			p.pos++
+		}
+		p.tok = tokNumber
+	default:
+		p.pos++
+		p.tok = tokOp
+	}
+	p.lit = src[start:p.pos]
+}
+
+func (p *typeParser) expect(op string) {
+	if p.tok != tokOp || p.lit != op {
+		p.errorf("expected '%s', found %s", op, p.desc())
+		return
+	}
+	p.next()
+}
+
+func (p *typeParser) isOp(op string) bool {
+	return p.tok == tokOp && p.lit == op
+}
+
+// X | Y | ...
+func (p *typeParser) parseUnion() Type {
+	t := p.parsePrimary()
+	if !p.isOp("|") {
+		return t
+	}
+	u := &UnionType{Types: []Type{t}}
+	for p.err == nil && p.isOp("|") {
+		p.next()
+		u.Types = append(u.Types, p.parsePrimary())
+	}
+	return u
+}
+
+func (p *typeParser) parsePrimary() Type {
+	switch p.tok {
+	case tokName:
+		name := p.lit
+		p.next()
+		if !p.isOp("[") {
+			if specialForm(name) == "Callable" {
+				return &CallableType{}
+			}
+			return &NameType{Name: name}
+		}
+		return p.parseSubscript(name)
+	case tokEllipsis:
+		p.next()
+		return &NameType{Name: "..."}
+	case tokString:
+		return p.parseForwardRef()
+	}
+	p.errorf("expected type, found %s", p.desc())
+	return nil
+}
+
+// 'X', the quoted annotation is parsed on its own
+func (p *typeParser) parseForwardRef() Type {
+	quoted, off := p.lit, p.off
+	p.next()
+	t, err := ParseType(unquote(quoted))
+	if err != nil {
+		e := err.(*ParseError)
+		p.err = &ParseError{Src: p.src, Pos: off + 1 + e.Pos, Msg: e.Msg}
+		return nil
+	}
+	return &ForwardRef{Elem: t}
+}
+
+// name[...], with the current token at [
+func (p *typeParser) parseSubscript(name string) Type {
+	p.next()
+	var t Type
+	switch specialForm(name) {
+	case "Union":
+		t = &UnionType{Types: p.parseTypeList()}
+	case "Optional":
+		t = &OptionalType{Elem: p.parseUnion()}
+	case "Callable":
+		t = p.parseCallable()
+	case "Literal":
+		t = &LiteralType{Values: p.parseExprList()}
+	case "Annotated":
+		elem := p.parseUnion()
+		p.expect(",")
+		t = &AnnotatedType{Elem: elem, Metadata: p.parseExprList()}
+	default:
+		t = &GenericType{Base: &NameType{Name: name}, Args: p.parseTypeList()}
+	}
+	p.expect("]")
+	return t
+}
+
+// Callable[[A, B], R], Callable[..., R]
+func (p *typeParser) parseCallable() Type {
+	t := &CallableType{}
+	if p.tok == tokEllipsis {
+		p.next()
+	} else {
+		p.expect("[")
+		t.Params = []Type{}
+		if !p.isOp("]") {
+			t.Params = p.parseTypeList()
+		}
+		p.expect("]")
+	}
+	p.expect(",")
+	t.Result = p.parseUnion()
+	return t
+}
+
+// T1, T2, ... up to ]
+func (p *typeParser) parseTypeList() (list []Type) {
+	for p.err == nil {
+		if p.isOp("(") { // tuple[()]
+			p.next()
+			p.expect(")")
+			list = append(list, &NameType{Name: "()"})
+		} else {
+			list = append(list, p.parseUnion())
+		}
+		if !p.isOp(",") {
+			break
+		}
+		p.next()
+		if p.isOp("]") { // trailing comma
+			break
+		}
+	}
+	return
+}
+
+// literal values or metadata up to ], kept as written
+func (p *typeParser) parseExprList() (list []string) {
+	for p.err == nil {
+		start, depth := p.off, 0
+		for p.err == nil && p.tok != tokEOF {
+			if p.tok == tokOp {
+				switch p.lit {
+				case "(", "[", "{":
+					depth++
+				case ")", "]", "}":
+					depth--
+				case ",":
+				default:
+					p.next()
+					continue
+				}
+				if depth < 0 || depth == 0 && p.lit == "," {
+					break
+				}
+			}
+			p.next()
+		}
+		if depth > 0 || p.tok == tokEOF {
+			p.errorf("expected ']', found %s", p.desc())
+			return
+		}
+		expr := strings.TrimSpace(p.src[start:p.off])
+		if expr == "" {
+			p.errorf("expected expression, found %s", p.desc())
+			return
+		}
+		list = append(list, expr)
+		if !p.isOp(",") {
+			return
+		}
+		p.next()
+	}
+	return
+}
+
+// name of the typing special form, or "" for other types
+func specialForm(name string) string {
+	mod, form := "", name
+	if pos := strings.LastIndexByte(name, '.'); pos >= 0 {
+		mod, form = name[:pos], name[pos+1:]
+	}
+	switch mod {
+	case "", "typing", "typing_extensions", "t":
+	case "collections.abc":
+		if form != "Callable" {
+			return ""
+		}
+	default:
+		return ""
+	}
+	switch form {
+	case "Union", "Optional", "Callable", "Literal", "Annotated":
+		return form
+	}
+	return ""
+}
+
+func unquote(s string) string {
+	s = s[1 : len(s)-1]
+	return strings.NewReplacer(`\'`, `'`, `\"`, `"`, `\\`, `\`).Replace(s)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
@@ -0,0 +1,113 @@
+package pysig
+
+import (
+	"reflect"
+	"testing"
+)
+
+func nameType(s string) *NameType {
+	return &NameType{Name: s}
+}
+
+func TestParseType(t *testing.T) {
+	type testCase struct {
+		src string
+		typ Type
+	}
+	cases := []testCase{
+		{"int", nameType("int")},
+		{" numpy.ndarray ", nameType("numpy.ndarray")},
+		{"None", nameType("None")},
+		{"list[int]", &GenericType{Base: nameType("list"), Args: []Type{nameType("int")}}},
+		{"Dict[str, List[int]]", &GenericType{Base: nameType("Dict"), Args: []Type{
+			nameType("str"),
+			&GenericType{Base: nameType("List"), Args: []Type{nameType("int")}},
+		}}},
+		{"tuple[int, ...]", &GenericType{Base: nameType("tuple"), Args: []Type{nameType("int"), nameType("...")}}},
+		{"tuple[()]", &GenericType{Base: nameType("tuple"), Args: []Type{nameType("()")}}},
+		{"Union[tuple[()], tuple[int]]", &UnionType{Types: []Type{
+			&GenericType{Base: nameType("tuple"), Args: []Type{nameType("()")}},
+			&GenericType{Base: nameType("tuple"), Args: []Type{nameType("int")}},
+		}}},
+		{"str | None", &UnionType{Types: []Type{nameType("str"), nameType("None")}}},
+		{"typing.Union[int, float]", &UnionType{Types: []Type{nameType("int"), nameType("float")}}},
+		{"Optional[np.dtype]", &OptionalType{Elem: nameType("np.dtype")}},
+		{"Callable[[int, str], bool]", &CallableType{
+			Params: []Type{nameType("int"), nameType("str")},
+			Result: nameType("bool"),
+		}},
+		{"Callable[[], None]", &CallableType{Params: []Type{}, Result: nameType("None")}},
+		{"collections.abc.Callable[..., Any]", &CallableType{Result: nameType("Any")}},
+		{"Callable", &CallableType{}},
+		{"Literal['left', \"right\", -1, Color.RED]", &LiteralType{
+			Values: []string{"'left'", "\"right\"", "-1", "Color.RED"},
+		}},
+		{"Annotated[int, ValueRange(-10, 5), 'doc']", &AnnotatedType{
+			Elem:     nameType("int"),
+			Metadata: []string{"ValueRange(-10, 5)", "'doc'"},
+		}},
+		{"'DataFrame'", &ForwardRef{Elem: nameType("DataFrame")}},
+		{"'str | None'", &ForwardRef{Elem: &UnionType{Types: []Type{nameType("str"), nameType("None")}}}},
+		{"list['Series'] | None", &UnionType{Types: []Type{
+			&GenericType{Base: nameType("list"), Args: []Type{&ForwardRef{Elem: nameType("Series")}}},
+			nameType("None"),
+		}}},
+	}
+	for _, c := range cases {
+		typ, err := ParseType(c.src)
+		if err != nil {
+			t.Fatalf("ParseType(%q): %v", c.src, err)
+		}
+		if !reflect.DeepEqual(typ, c.typ) {
+			t.Fatalf("ParseType(%q) = %s, want %s", c.src, typ, c.typ)
+		}
+	}
+}
+
+func TestParseTypeError(t *testing.T) {
+	type testCase struct {
+		src string
+		pos int
+	}
+	cases := []testCase{
+		{"", 0},
+		{"list[int", 8},
+		{"int str", 4},
+		{"Callable[int, str]", 9},
+		{"'str | '", 7},
+		{"Literal['a'", 11},
+		{"'unterminated", 0},
+		{"tuple[(int)]", 7},
+		{"()", 0},
+	}
+	for _, c := range cases {
+		_, err := ParseType(c.src)
+		e, ok := err.(*ParseError)
+		if !ok {
+			t.Fatalf("ParseType(%q) error = %v, want *ParseError", c.src, err)
+		}
+		if e.Pos != c.pos {
+			t.Fatalf("ParseType(%q) error at %d, want %d: %v", c.src, e.Pos, c.pos, e)
+		}
+	}
+}
+
+func TestTypeString(t *testing.T) {
+	for _, src := range []string{
+		"Dict[str, int | None]",
+		"Callable[[int], str]",
+		"Callable[..., Any]",
+		"tuple[()]",
+		"Optional[Literal['a', 1]]",
+		"Annotated[int, Gt(0)]",
+		"'DataFrame'",
+	} {
+		typ, err := ParseType(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if typ.String() != src {
+			t.Fatalf("ParseType(%q).String() = %q", src, typ.String())
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package pysig
+
+import "strings"
+
+const (
+	tWord   = iota // name, number, *args, /, ... and other text
+	tString        // 'a', "a", '''a''', r'\d', with escapes
+	tRepr          // <object at 0x10>, <function <lambda>>
+	tArrow         // ->
+	tPunct         // ( ) [ ] { } , : =
+)
+
+type token struct {
+	kind       int
+	start, end int // src[start:end] is the token text
+}
+
+// lexer splits a signature into tokens. String literals and reprs are
+// single tokens, so brackets and commas in them are not counted.
+type lexer struct {
+	src  string
+	toks []token
+}
+
+func lex(src string) *lexer {
+	l := &lexer{src: src}
+	for pos := 0; pos < len(src); {
+		c := src[pos]
+		switch {
+		case isSpace(c):
+			pos++
+			continue
+		case strings.IndexByte("()[]{},:=", c) >= 0:
+			l.add(tPunct, pos, pos+1)
+		case strings.HasPrefix(src[pos:], "->"):
+			l.add(tArrow, pos, pos+2)
+		case c == '<':
+			if end := scanRepr(src, pos); end > 0 {
+				l.add(tRepr, pos, end)
+			} else {
+				l.add(tWord, pos, pos+1)
+			}
+		default:
+			if quote := stringStart(src, pos); quote >= 0 {
+				l.add(tString, pos, scanString(src, quote))
+			} else {
+				end := pos + 1
+				for end < len(src) && isWordPart(src, end) {
+					end++
+				}
+				l.add(tWord, pos, end)
+			}
+		}
+		pos = l.toks[len(l.toks)-1].end
+	}
+	return l
+}
+
+func (l *lexer) add(kind, start, end int) {
+	l.toks = append(l.toks, token{kind, start, end})
+}
+
+func (l *lexer) text(i int) string {
+	return l.src[l.toks[i].start:l.toks[i].end]
+}
+
+// text of tokens [from, to), as written
+func (l *lexer) slice(from, to int) string {
+	if from >= to {
+		return ""
+	}
+	return strings.TrimSpace(l.src[l.toks[from].start:l.toks[to-1].end])
+}
+
+func (l *lexer) is(i int, punct byte) bool {
+	return i < len(l.toks) && l.toks[i].kind == tPunct && l.src[l.toks[i].start] == punct
+}
+
+// index of the bracket closing the one at i, or -1
+func (l *lexer) matching(i int) int {
+	depth := 0
+	for ; i < len(l.toks); i++ {
+		if l.toks[i].kind != tPunct {
+			continue
+		}
+		switch l.src[l.toks[i].start] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isWordPart(src string, pos int) bool {
+	c := src[pos]
+	if isSpace(c) || strings.IndexByte("()[]{},:=<'\"", c) >= 0 {
+		return false
+	}
+	return !strings.HasPrefix(src[pos:], "->")
+}
+
+// offset of the opening quote if a string literal, with an optional
+// prefix like r, b, rb, f, starts at pos, or else -1
+func stringStart(src string, pos int) int {
+	for i := pos; i < len(src) && i < pos+3; i++ {
+		switch src[i] {
+		case '\'', '"':
+			return i
+		case 'r', 'R', 'b', 'B', 'u', 'U', 'f', 'F':
+		default:
+			return -1
+		}
+	}
+	return -1
+}
+
+// end offset of the string literal whose opening quote is at pos,
+// the rest of src if unterminated
+func scanString(src string, pos int) int {
+	quote := src[pos : pos+1]
+	if strings.HasPrefix(src[pos:], strings.Repeat(quote, 3)) {
+		quote = src[pos : pos+3]
+	}
+	for i := pos + len(quote); i < len(src); i++ {
+		if src[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.HasPrefix(src[i:], quote) {
+			return i + len(quote)
+		}
+	}
+	return len(src)
+}
+
+// end offset of the repr like <object at 0x10> starting at pos, or -1
+func scanRepr(src string, pos int) int {
+	depth := 0
+	for i := pos; i < len(src); i++ {
+		switch src[i] {
+		case '<':
+			depth++
+		case '>':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		case '\'', '"':
+			i = scanString(src, i) - 1
+		case '\n':
+			return -1
+		}
+	}
+	return -1
+}
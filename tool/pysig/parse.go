@@ -1,174 +1,213 @@
 package pysig
 
-import "strings"
+import (
+	"errors"
+	"strings"
+)
+
+// parameter kinds, named as inspect.Parameter kinds like symbol.Param.Kind
+const (
+	PositionalOnly      = "POSITIONAL_ONLY"
+	PositionalOrKeyword = "POSITIONAL_OR_KEYWORD"
+	VarPositional       = "VAR_POSITIONAL" // *args
+	KeywordOnly         = "KEYWORD_ONLY"
+	VarKeyword          = "VAR_KEYWORD" // **kwargs
+)
 
 type Arg struct {
-	Name     string `json:"name"`
+	Name     string `json:"name"` // without * of *args and ** of **kwargs
 	Type     string `json:"type"`
 	DefVal   string `json:"defVal"`
-	Optional bool   `json:"optional"`
+	Optional bool   `json:"optional"` // in [] of a docstring signature, e.g. f(a[, b])
+	Kind     string `json:"kind"`
 }
 
-func Parse(sig string) (args []*Arg) {
-	// get signature between ()
-	end := findMatchingBracket(sig, '(', ')')
-	if end == -1 {
-		return
+// Signature is a parsed signature, e.g. (a: int, b=1) -> str.
+type Signature struct {
+	Args   []*Arg `json:"args"`
+	Return string `json:"return"` // return annotation, "" if none
+	Err    error  `json:"-"`      // why the signature is rejected, see Check
+}
+
+// ParseSignature parses the arguments and the return annotation of a signature.
+func ParseSignature(sig string) *Signature {
+	args, err := parse(sig)
+	return &Signature{Args: args, Return: parseReturn(sig), Err: err}
+}
+
+var (
+	errNoParams   = errors.New("no parameter list")
+	errUnbalanced = errors.New("unbalanced brackets in the parameter list")
+)
+
+// Check returns why the arguments of a signature can't be parsed, nil if
+// they can. A signature like (a=[1[, b]) has no closed parameter list, and
+// Parse returns no arguments for it, not even a.
+func Check(sig string) error {
+	_, err := parse(sig)
+	return err
+}
+
+// (a, b) -> 'Index' returns 'Index'
+func parseReturn(sig string) string {
+	l := lex(sig)
+	end := l.params()
+	if end < 0 || end+1 >= len(l.toks) || l.toks[end+1].kind != tArrow {
+		return ""
+	}
+	ret := sig[l.toks[end+1].end:]
+	if pos := strings.IndexByte(ret, '\n'); pos >= 0 { // signature line of a docstring
+		ret = ret[:pos]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(ret), ":"))
+}
+
+// Parse parses the arguments of a signature. The / and * markers are
+// resolved into the kinds of the arguments, and not returned. It returns
+// nil if the signature is rejected, see Check.
+func Parse(sig string) []*Arg {
+	args, _ := parse(sig)
+	return args
+}
+
+func parse(sig string) ([]*Arg, error) {
+	l := lex(sig)
+	if !l.is(0, '(') {
+		return nil, errNoParams
+	}
+	end := l.params()
+	if end < 0 {
+		return nil, errUnbalanced
+	}
+	return resolveKinds(l.parseArgs(1, end)), nil
+}
+
+// set the kinds of args by the markers among them, and drop the markers:
+//
+//	(a, /, b, *, c) -> a: PositionalOnly, b: PositionalOrKeyword, c: KeywordOnly
+//	(*args, c, **kwargs) -> args: VarPositional, c: KeywordOnly, kwargs: VarKeyword
+//
+// A bare ** is named kwargs, like the ... after a default value.
+func resolveKinds(args []*Arg) []*Arg {
+	kind := PositionalOrKeyword
+	list := args[:0]
+	for _, arg := range args {
+		name := strings.ReplaceAll(arg.Name, "\\*", "*") // \*args in reST
+		switch {
+		case name == "/":
+			for _, prev := range list {
+				if prev.Kind == PositionalOrKeyword {
+					prev.Kind = PositionalOnly
+				}
+			}
+			continue
+		case name == "*":
+			kind = KeywordOnly
+			continue
+		case name == "...":
+			if n := len(list); n > 0 && list[n-1].DefVal != "" {
+				arg.Name, arg.Kind = "kwargs", VarKeyword
+			} else {
+				arg.Name, arg.Kind = "args", VarPositional
+				kind = KeywordOnly
+			}
+		case name == "**":
+			arg.Name, arg.Kind = "kwargs", VarKeyword
+		case strings.HasPrefix(name, "**"):
+			arg.Name, arg.Kind = name[2:], VarKeyword
+		case strings.HasPrefix(name, "*"):
+			arg.Name, arg.Kind = name[1:], VarPositional
+			kind = KeywordOnly
+		default:
+			arg.Kind = kind
+		}
+		list = append(list, arg)
 	}
-	sig = sig[:end+1]
-	sig = strings.TrimPrefix(sig, "(")
+	return list
+}
 
-	for {
-		sig = strings.TrimSpace(sig)
-		if len(sig) == 0 {
-			return
+// index of the ) closing the parameter list, or -1
+func (l *lexer) params() int {
+	if !l.is(0, '(') {
+		return -1
+	}
+	return l.matching(0)
+}
+
+// arguments in tokens [i, end)
+func (l *lexer) parseArgs(i, end int) (args []*Arg) {
+	for i < end {
+		if l.is(i, ',') {
+			i++
+			continue
 		}
-		if sig[0] == '[' {		// optional args
+		if l.is(i, '[') { // optional args
 			var optArgs []*Arg
-			optArgs, sig = parseOptArgs(sig)
-			for _, arg := range optArgs {
-				arg.Optional = true
-			}
+			optArgs, i = l.parseOptArgs(i, end)
 			args = append(args, optArgs...)
 			continue
 		}
-		if sig[0] == '(' {     // (a1, a2, ...)
-			pos := findMatchingBracket(sig, '(', ')')
-			if pos > 0 {
-				name := strings.TrimSpace(sig[:pos+1])
-				arg := &Arg{Name: name}
-				args = append(args, arg)
-				sig = sig[pos+1:]
+		if l.is(i, '(') { // (a1, a2, ...)
+			close := l.matching(i)
+			if close < 0 || close >= end {
+				return
 			}
+			args = append(args, &Arg{Name: l.slice(i, close+1)})
+			i = close + 1
 			continue
 		}
-		// (a) (, a, ) (a:int) (a=1) (a[, b])
-		pos := strings.IndexAny(sig, ",:=[)")
-		if pos < 0 || (pos == 0 && sig[0] == ')') {
-			return
+		// (a) (a:int) (a=1) (a[, b])
+		start := i
+		for i < end && !l.is(i, ',') && !l.is(i, ':') && !l.is(i, '=') && !l.is(i, '[') {
+			i++
 		}
-		name := strings.TrimSpace(sig[:pos])
+		name := l.slice(start, i)
 		if name == "" {
-			sig = sig[1:]
+			i++
 			continue
 		}
-		if name == "..." {
-			length := len(args)
-			if length > 0 && args[length-1].DefVal != "" {
-				name = "**kwargs"
-			}else {
-				name = "**args"
-			}
-		}
 		arg := &Arg{Name: name}
 		args = append(args, arg)
-		split := sig[pos]    // , : = ) [
-		switch split {
-		case ',':
-			sig = sig[pos+1:]
-			continue
-		case ':':
-			arg.Type, sig = parseType(sig[pos+1:])
-			if sig[0] == '=' {
-				arg.DefVal, sig = parseDefVal(sig[1:])
-			}
-		case '=':
-			arg.DefVal, sig = parseDefVal(sig[pos+1:])
-		case '[':
-			var optArgs []*Arg
-			optArgs, sig = parseOptArgs(sig[pos:])
-			for _, arg := range optArgs {
-				arg.Optional = true
-			}
-			args = append(args, optArgs...)
-			continue
-		case ')':
-			return
+		if l.is(i, ':') {
+			arg.Type, i = l.parseExpr(i+1, end, true)
 		}
-		sig = strings.TrimPrefix(sig, ",")
-	}
-}
-
-
-func parseOptArgs(sig string) (optArgs []*Arg, newSig string) {
-	end := findMatchingBracket(sig, '[', ']')
-	if end == -1 {
-		return
-	}
-	optArgs = Parse("(" + sig[1:end] + ")")
-	newSig = sig[end+1:]
-	return
-}
-
-
-
-
-// default value pairs
-var pairs = map[byte]byte {
-	'(': ')',
-	'[': ']',
-	'{': '}',
-}
-
-
-func parseDefVal(sig string) (defVal string, newSig string) {
-	sig = strings.TrimSpace(sig)
-	// list, tuple, dict
-	if close, exists := pairs[sig[0]]; exists {
-		idx := findMatchingBracket(sig, sig[0], close)
-		if idx > 0 {
-			defVal = strings.TrimSpace(sig[0:idx+1])
-			newSig = sig[idx+1:]
-			return
+		if l.is(i, '=') {
+			arg.DefVal, i = l.parseExpr(i+1, end, false)
 		}
 	}
-	pos := strings.IndexAny(sig, "[,)")
-	if pos > 0 {
-		defVal = strings.TrimSpace(sig[:pos])
-		newSig = sig[pos:]
-		return
-	}
 	return
 }
 
-
-func parseType(sig string) (typeStr string, newSig string) {
-	right := strings.IndexAny(sig, "=,)[")
-	if right < 0 {
-		return "", sig
+// [a, b=1] -> optional a, b
+func (l *lexer) parseOptArgs(i, end int) (optArgs []*Arg, next int) {
+	close := l.matching(i)
+	if close < 0 || close >= end {
+		return nil, end
 	}
-	if sig[right] == '[' { 		// 'Union[int, float]'
-		tmp := strings.TrimSpace(sig[right+1:])
-		if len(tmp) > 0 && tmp[0] != ',' {
-			length := findMatchingBracket(sig[right:], '[', ']')
-			right = right + length
-			idx := strings.IndexAny(sig[right:], "=,)")
-			right = right + idx
-		}
+	optArgs = l.parseArgs(i+1, close)
+	for _, arg := range optArgs {
+		arg.Optional = true
 	}
-	typeStr = strings.TrimSpace(sig[:right])
-	newSig = sig[right:]
-	return
+	return optArgs, close + 1
 }
 
-
-// start=0, return end index, else return -1
-func findMatchingBracket(sig string, open, close byte) int {
-	if len(sig) == 0 || sig[0] != open {
-		return -1
-	}
-
-	count := 1
-	for i := 1; i < len(sig); i++ {
-		switch sig[i] {
-		case open:
-			count++
-		case close:
-			count--
-			if count == 0 {
-				return i
+// a type or default value as written, up to the next , or optional args.
+// Brackets in it, e.g. Union[int, float] or (1, 2), are skipped as a whole.
+func (l *lexer) parseExpr(i, end int, isType bool) (expr string, next int) {
+	start := i
+	for i < end && !l.is(i, ',') && !(isType && l.is(i, '=')) {
+		if l.is(i, '[') && l.is(i+1, ',') {
+			break // optional args, e.g. a=1[, b]
+		}
+		if l.is(i, '(') || l.is(i, '[') || l.is(i, '{') {
+			close := l.matching(i)
+			if close < 0 || close >= end {
+				return l.slice(start, end), end
 			}
+			i = close + 1
+			continue
 		}
+		i++
 	}
-	return -1
+	return l.slice(start, i), i
 }
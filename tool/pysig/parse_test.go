@@ -31,26 +31,23 @@ func TestParse(t *testing.T) {
 		}},
 		{"(start=None, *, unit: 'str | None' = None) -> 'TimedeltaIndex'", []*Arg{
 			{Name: "start", DefVal: "None"},
-			{Name: "*"},
-			{Name: "unit", Type: "'str | None'", DefVal: "None"},
+			{Name: "unit", Type: "'str | None'", DefVal: "None", Kind: KeywordOnly},
 		}},
 		{"([start,] stop[, step,], dtype=None, *, device=None, like=None)", []*Arg{
 			{Name: "start", Optional: true},
 			{Name: "stop"},
 			{Name: "step", Optional: true},
 			{Name: "dtype", DefVal: "None"},
-			{Name: "*"},
-			{Name: "device", DefVal: "None"},
-			{Name: "like", DefVal: "None"},
+			{Name: "device", DefVal: "None", Kind: KeywordOnly},
+			{Name: "like", DefVal: "None", Kind: KeywordOnly},
 		}},
 		{"([start, ]stop, [step, ]dtype=None, *, device=None, like=None)", []*Arg{
 			{Name: "start", Optional: true},
 			{Name: "stop"},
 			{Name: "step", Optional: true},
 			{Name: "dtype", DefVal: "None"},
-			{Name: "*"},
-			{Name: "device", DefVal: "None"},
-			{Name: "like", DefVal: "None"},
+			{Name: "device", DefVal: "None", Kind: KeywordOnly},
+			{Name: "like", DefVal: "None", Kind: KeywordOnly},
 		}},
 		{"( (a1, a2, ...), axis=0, out=None, dtype=None, casting=\"same_kind\" )", []*Arg{
 			{Name: "(a1, a2, ...)"},
@@ -60,51 +57,45 @@ func TestParse(t *testing.T) {
 			{Name: "casting", DefVal: "\"same_kind\""},
 		}},
 		{"(x1, x2, /, out=None, *, where=True, casting='same_kind', order='K', dtype=None, subok=True[, signature])", []*Arg{
-			{Name: "x1"},
-			{Name: "x2"},
-			{Name: "/"},
+			{Name: "x1", Kind: PositionalOnly},
+			{Name: "x2", Kind: PositionalOnly},
 			{Name: "out", DefVal: "None"},
-			{Name: "*"},
-			{Name: "where", DefVal: "True"},
-			{Name: "casting", DefVal: "'same_kind'"},
-			{Name: "order", DefVal: "'K'"},
-			{Name: "dtype", DefVal: "None"},
-			{Name: "subok", DefVal: "True"},
-			{Name: "signature", Optional: true},
+			{Name: "where", DefVal: "True", Kind: KeywordOnly},
+			{Name: "casting", DefVal: "'same_kind'", Kind: KeywordOnly},
+			{Name: "order", DefVal: "'K'", Kind: KeywordOnly},
+			{Name: "dtype", DefVal: "None", Kind: KeywordOnly},
+			{Name: "subok", DefVal: "True", Kind: KeywordOnly},
+			{Name: "signature", Optional: true, Kind: KeywordOnly},
 		}},
 		{"(x[, out1, out2], / [, out=(None, None)], *, where=True, casting='same_kind', order='K', dtype=None, subok=True[, signature, extobj])", []*Arg{
-			{Name: "x"},
-			{Name: "out1", Optional: true},
-			{Name: "out2", Optional: true},
-			{Name: "/"},
+			{Name: "x", Kind: PositionalOnly},
+			{Name: "out1", Optional: true, Kind: PositionalOnly},
+			{Name: "out2", Optional: true, Kind: PositionalOnly},
 			{Name: "out", DefVal: "(None, None)", Optional: true},
-			{Name: "*"},
-			{Name: "where", DefVal: "True"},
-			{Name: "casting", DefVal: "'same_kind'"},
-			{Name: "order", DefVal: "'K'"},
-			{Name: "dtype", DefVal: "None"},
-			{Name: "subok", DefVal: "True"},
-			{Name: "signature", Optional: true},
-			{Name: "extobj", Optional: true},
+			{Name: "where", DefVal: "True", Kind: KeywordOnly},
+			{Name: "casting", DefVal: "'same_kind'", Kind: KeywordOnly},
+			{Name: "order", DefVal: "'K'", Kind: KeywordOnly},
+			{Name: "dtype", DefVal: "None", Kind: KeywordOnly},
+			{Name: "subok", DefVal: "True", Kind: KeywordOnly},
+			{Name: "signature", Optional: true, Kind: KeywordOnly},
+			{Name: "extobj", Optional: true, Kind: KeywordOnly},
 		}},
 		{"(op1=func1, op2=func2, ...)", []*Arg{
 			{Name: "op1", DefVal: "func1"},
 			{Name: "op2", DefVal: "func2"},
-			{Name: "**kwargs"},
+			{Name: "kwargs", Kind: VarKeyword},
 		}},
 		{"(*args, **kwargs)", []*Arg{
-			{Name: "*args"},
-			{Name: "**kwargs"},
+			{Name: "args", Kind: VarPositional},
+			{Name: "kwargs", Kind: VarKeyword},
 		}},
 		{"(start: 'Union[int, float]', stop: 'Union[int, float]', /, num: 'int', *, dtype: 'Optional[Dtype]' = None, device: 'Optional[Device]' = None, endpoint: 'bool' = True) -> 'Array'", []*Arg{
-			{Name: "start", Type: "'Union[int, float]'"},
-			{Name: "stop", Type: "'Union[int, float]'"},
-			{Name: "/"},
+			{Name: "start", Type: "'Union[int, float]'", Kind: PositionalOnly},
+			{Name: "stop", Type: "'Union[int, float]'", Kind: PositionalOnly},
 			{Name: "num", Type: "'int'"},
-			{Name: "*"},
-			{Name: "dtype", Type: "'Optional[Dtype]'", DefVal: "None"},
-			{Name: "device", Type: "'Optional[Device]'", DefVal: "None"},
-			{Name: "endpoint", Type: "'bool'", DefVal: "True"},
+			{Name: "dtype", Type: "'Optional[Dtype]'", DefVal: "None", Kind: KeywordOnly},
+			{Name: "device", Type: "'Optional[Device]'", DefVal: "None", Kind: KeywordOnly},
+			{Name: "endpoint", Type: "'bool'", DefVal: "True", Kind: KeywordOnly},
 		}},
 		{"(input, k=1, dims=[0,1]) -> Tensor", []*Arg{
 			{Name: "input"},
@@ -114,7 +105,54 @@ func TestParse(t *testing.T) {
 		{"(start: int[, step], ...)", []*Arg{
 			{Name: "start", Type: "int"},
 			{Name: "step", Optional: true},
-			{Name: "**args"},
+			{Name: "args", Kind: VarPositional},
+		}},
+		{`(a, \*args, key=None, \*\*kwargs)`, []*Arg{
+			{Name: "a"},
+			{Name: "args", Kind: VarPositional},
+			{Name: "key", DefVal: "None", Kind: KeywordOnly},
+			{Name: "kwargs", Kind: VarKeyword},
+		}},
+		{`(sep=',', fmt='(%d)', chars='[]', quote="'", esc='\'', end=None)`, []*Arg{
+			{Name: "sep", DefVal: `','`},
+			{Name: "fmt", DefVal: `'(%d)'`},
+			{Name: "chars", DefVal: `'[]'`},
+			{Name: "quote", DefVal: `"'"`},
+			{Name: "esc", DefVal: `'\''`},
+			{Name: "end", DefVal: "None"},
+		}},
+		{`(doc='''a, (b]''', pat=r'\d+[,;]', data=b"x)")`, []*Arg{
+			{Name: "doc", DefVal: `'''a, (b]'''`},
+			{Name: "pat", DefVal: `r'\d+[,;]'`},
+			{Name: "data", DefVal: `b"x)"`},
+		}},
+		{"(obj=<object object at 0x7f0c>, key=<function <lambda> at 0x10>, n=1)", []*Arg{
+			{Name: "obj", DefVal: "<object object at 0x7f0c>"},
+			{Name: "key", DefVal: "<function <lambda> at 0x10>"},
+			{Name: "n", DefVal: "1"},
+		}},
+		{`(filepath_or_buffer: 'FilePath | ReadCsvBuffer[bytes] | ReadCsvBuffer[str]', *, sep: 'str | None | lib.NoDefault' = <no_default>, delimiter: 'str | None | lib.NoDefault' = None, na_values: 'Sequence[str] | Mapping[str, Sequence[str]] | None' = None, quotechar: 'str' = '"', decimal: 'str' = '.', dtype_backend: 'DtypeBackend | lib.NoDefault' = <no_default>) -> 'DataFrame | TextFileReader'`, []*Arg{
+			{Name: "filepath_or_buffer", Type: "'FilePath | ReadCsvBuffer[bytes] | ReadCsvBuffer[str]'"},
+			{Name: "sep", Type: "'str | None | lib.NoDefault'", DefVal: "<no_default>", Kind: KeywordOnly},
+			{Name: "delimiter", Type: "'str | None | lib.NoDefault'", DefVal: "None", Kind: KeywordOnly},
+			{Name: "na_values", Type: "'Sequence[str] | Mapping[str, Sequence[str]] | None'", DefVal: "None", Kind: KeywordOnly},
+			{Name: "quotechar", Type: "'str'", DefVal: `'"'`, Kind: KeywordOnly},
+			{Name: "decimal", Type: "'str'", DefVal: "'.'", Kind: KeywordOnly},
+			{Name: "dtype_backend", Type: "'DtypeBackend | lib.NoDefault'", DefVal: "<no_default>", Kind: KeywordOnly},
+		}},
+		{"(a, **)", []*Arg{
+			{Name: "a"},
+			{Name: "kwargs", Kind: VarKeyword},
+		}},
+		{"(a, *, b, **: int)", []*Arg{
+			{Name: "a"},
+			{Name: "b", Kind: KeywordOnly},
+			{Name: "kwargs", Type: "int", Kind: VarKeyword},
+		}},
+		{"(a: Dict[str, int] = {'a': 1, 'b': (2, 3)}, f=func(1, 2), b=2)", []*Arg{
+			{Name: "a", Type: "Dict[str, int]", DefVal: "{'a': 1, 'b': (2, 3)}"},
+			{Name: "f", DefVal: "func(1, 2)"},
+			{Name: "b", DefVal: "2"},
 		}},
 	}
 	for _, c := range cases {
@@ -124,10 +162,63 @@ func TestParse(t *testing.T) {
 		}
 		for i, arg := range args {
 			want := c.args[i]
-			if arg.Name != want.Name || arg.Type != want.Type || arg.DefVal != want.DefVal || arg.Optional != want.Optional {
+			if want.Kind == "" {
+				want.Kind = PositionalOrKeyword
+			}
+			if arg.Name != want.Name || arg.Type != want.Type || arg.DefVal != want.DefVal || arg.Optional != want.Optional || arg.Kind != want.Kind {
 				t.Fatalf("%s: args[%v] = %v, want %v", c.sig, i, arg, want)
 			}
 		}
 	}
 }
 
+
+func TestParseSignature(t *testing.T) {
+	type testCase struct {
+		sig   string
+		nargs int
+		ret   string
+	}
+	cases := []testCase{
+		{"()", 0, ""},
+		{"() -> int", 0, "int"},
+		{"(a) -> int", 1, "int"},
+		{"(start=None, *, unit: 'str | None' = None) -> 'TimedeltaIndex'", 2, "'TimedeltaIndex'"},
+		{"(start: 'Union[int, float]', stop: 'Union[int, float]', /, num: 'int', *, dtype: 'Optional[Dtype]' = None, device: 'Optional[Device]' = None, endpoint: 'bool' = True) -> 'Array'", 6, "'Array'"},
+		{"(input, k=1, dims=[0,1]) -> Tensor", 3, "Tensor"},
+		{"(a, b) -> Tuple[int, str]\n\nReturn a pair.", 2, "Tuple[int, str]"},
+		{"(self) -> None:", 1, "None"},
+		{"(a, b)", 2, ""},
+		{"(sep=')', end='->') -> str", 2, "str"},
+	}
+	for _, c := range cases {
+		sig := ParseSignature(c.sig)
+		if len(sig.Args) != c.nargs || sig.Return != c.ret {
+			t.Fatalf("%s: len(args) = %v, return = %q, want %v, %q", c.sig, len(sig.Args), sig.Return, c.nargs, c.ret)
+		}
+	}
+}
+
+func TestParseSignatureError(t *testing.T) {
+	type testCase struct {
+		sig string
+		err error
+	}
+	cases := []testCase{
+		{"(a=[1[, b])", errUnbalanced},
+		{"(a, (b)", errUnbalanced},
+		{"a, b", errNoParams},
+		{"", errNoParams},
+		{"(a, **)", nil},
+		{"(a, *, b)", nil},
+	}
+	for _, c := range cases {
+		sig := ParseSignature(c.sig)
+		if sig.Err != c.err || Check(c.sig) != c.err {
+			t.Fatalf("%s: err = %v, want %v", c.sig, sig.Err, c.err)
+		}
+		if c.err != nil && sig.Args != nil {
+			t.Fatalf("%s: args = %v, want nil", c.sig, sig.Args)
+		}
+	}
+}
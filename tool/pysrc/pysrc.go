@@ -0,0 +1,313 @@
+// Package pysrc is a static analyzer of python source, the fallback of
+// modules that fail to import. It finds the top-level defs, classes and
+// assignments of pure python modules without running them.
+package pysrc
+
+import (
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"github.com/goplus/llpyg/symbol"
+	"github.com/goplus/llpyg/tool/pygen"
+	"github.com/goplus/llpyg/tool/pysig"
+)
+
+// Dumper dumps the modules another Dumper fails to import by static
+// analysis of their source, if found in Paths.
+type Dumper struct {
+	pygen.Dumper
+	Paths []string // dirs of python sources, e.g. site-packages
+}
+
+func (d *Dumper) Dump(moduleName string) (mod symbol.Module, err error) {
+	mod, err = d.Dumper.Dump(moduleName)
+	if err == nil {
+		return
+	}
+	file := Find(moduleName, d.Paths)
+	if file == "" {
+		return
+	}
+	static, serr := Load(moduleName, file)
+	if serr != nil {
+		return
+	}
+	log.Printf("%v, using static analysis of %s\n", err, file)
+	return static, nil
+}
+
+// Load reads the symbols of module moduleName from its source file.
+func Load(moduleName, file string) (mod symbol.Module, err error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return
+	}
+	return Scan(string(data)).Module(moduleName, file), nil
+}
+
+// Find returns the source file of a module in paths, "" if not found:
+//
+//	<path>/a/b.py
+//	<path>/a/b/__init__.py
+func Find(moduleName string, paths []string) string {
+	parts := strings.Split(moduleName, ".")
+	for _, path := range paths {
+		base := filepath.Join(append([]string{path}, parts...)...)
+		for _, file := range []string{base + ".py", filepath.Join(base, "__init__.py")} {
+			if isFile(file) {
+				return file
+			}
+		}
+	}
+	return ""
+}
+
+// Modules lists a package and its submodules found in paths, like pymodule
+// but without importing them: up to depth levels, skipping the private
+// and test modules. It is nil if the package is not found.
+func Modules(moduleName string, depth int, paths []string) (modules []string) {
+	file := Find(moduleName, paths)
+	if file == "" || depth < 1 {
+		return nil
+	}
+	modules = append(modules, moduleName)
+	if depth == 1 || filepath.Base(file) != "__init__.py" {
+		return
+	}
+	entries, _ := os.ReadDir(filepath.Dir(file))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			if !isFile(filepath.Join(filepath.Dir(file), name, "__init__.py")) {
+				continue
+			}
+		} else if name = strings.TrimSuffix(name, ".py"); name == entry.Name() {
+			continue
+		}
+		if strings.HasPrefix(name, "test") || strings.HasPrefix(name, "_") {
+			continue
+		}
+		modules = append(modules, Modules(moduleName+"."+name, depth-1, paths)...)
+	}
+	return
+}
+
+// DefaultPaths returns the dirs python finds modules in, as far as known
+// without running it: $PYTHONPATH and the site-packages of $PYTHONHOME.
+func DefaultPaths() (paths []string) {
+	paths = filepath.SplitList(os.Getenv("PYTHONPATH"))
+	if home := os.Getenv("PYTHONHOME"); home != "" {
+		dirs, _ := filepath.Glob(filepath.Join(home, "lib", "python3*", "site-packages"))
+		paths = append(paths, dirs...)
+	}
+	return
+}
+
+// Module converts the scanned file to the symbols of module moduleName.
+// Only the names in __all__ are kept if it is defined.
+func (f *File) Module(moduleName, file string) symbol.Module {
+	mod := symbol.Module{Name: moduleName, File: file, Static: true}
+	public := func(name string) bool {
+		if f.All == nil {
+			return true
+		}
+		for _, s := range f.All {
+			if s == name {
+				return true
+			}
+		}
+		return false
+	}
+	for _, fn := range f.Funcs {
+		if public(fn.Name) {
+			mod.Functions = append(mod.Functions, fn.symbol("function", false))
+		}
+	}
+	for _, c := range f.Classes {
+		if !public(c.Name) {
+			continue
+		}
+		cls := &symbol.Class{Name: c.Name, Doc: c.Doc, Bases: c.Bases}
+		if len(cls.Bases) == 0 {
+			cls.Bases = []string{"builtins.object"}
+		}
+		for _, fn := range c.Methods {
+			switch {
+			case hasDecorator(fn.Decorators, "staticmethod"):
+				cls.StaticMethods = append(cls.StaticMethods, fn.symbol("staticmethod", false))
+			case hasDecorator(fn.Decorators, "classmethod"):
+				// bound to the class, like the ones pydump gets by attribute lookup
+				cls.ClassMethods = append(cls.ClassMethods, fn.symbol("classmethod", true))
+			default:
+				cls.Methods = append(cls.Methods, fn.symbol("function", false))
+			}
+		}
+		mod.Classes = append(mod.Classes, cls)
+	}
+	for _, v := range f.Vars {
+		if !public(v.Name) {
+			continue
+		}
+		sym := &symbol.Symbol{Name: v.Name, Type: v.Annotation}
+		if typ, repr := literal(v.Value); typ != "" {
+			if sym.Type == "" {
+				sym.Type = typ
+			}
+			sym.Repr = repr
+		}
+		mod.Variables = append(mod.Variables, sym)
+	}
+	return mod
+}
+
+// the symbol of a def, without its first param if bound. The sig of a
+// def with @overload defs is the first of them, like the ones of stubs.
+func (fn *Func) symbol(typ string, bound bool) *symbol.Symbol {
+	var sigs []string
+	for _, overload := range fn.Overloads {
+		if bound {
+			overload = DropFirstParam(overload)
+		}
+		sigs = append(sigs, overload)
+	}
+	sig := fn.Sig
+	if bound {
+		sig = DropFirstParam(sig)
+	}
+	if len(sigs) > 0 {
+		sig = sigs[0]
+	}
+	parsed := pysig.ParseSignature(sig)
+	params := make([]*symbol.Param, len(parsed.Args))
+	for i, arg := range parsed.Args {
+		params[i] = &symbol.Param{Name: arg.Name, Kind: arg.Kind, Annotation: arg.Type, Default: arg.DefVal}
+		if _, repr := literal(arg.DefVal); repr != "" {
+			params[i].Default = repr // "" -> ''
+		}
+	}
+	return &symbol.Symbol{Name: fn.Name, Type: typ, Doc: fn.Doc, Sig: sig, Sigs: sigs, Params: params, Return: parsed.Return}
+}
+
+// DropFirstParam drops the first parameter of a signature, e.g. the cls
+// of a classmethod: (cls, a) -> str -> (a) -> str
+func DropFirstParam(sig string) string {
+	close := MatchingParen(sig, 0)
+	if close < 0 {
+		return sig
+	}
+	params := SplitCode(sig[1:close], ',')
+	return "(" + strings.TrimSpace(strings.Join(params[1:], ",")) + ")" + sig[close+1:]
+}
+
+// the type name and repr of a literal value, as pydump reports them for
+// variables, or "" if not a literal
+func literal(value string) (typ, repr string) {
+	switch value {
+	case "True", "False":
+		return "bool", value
+	case "None":
+		return "NoneType", value
+	}
+	if s, ok := stringLiteral(value); ok {
+		return "str", pyRepr(s)
+	}
+	if quote := strings.IndexAny(value, `'"`); quote >= 0 && quote <= 2 && SkipString(value, quote) == len(value) {
+		if p := strings.ToLower(value[:quote]); strings.Contains(p, "b") {
+			return "bytes", ""
+		}
+		return "", "" // f-string
+	}
+	num := strings.TrimPrefix(value, "-")
+	if num == "" || !(num[0] >= '0' && num[0] <= '9' || num[0] == '.') {
+		return "", ""
+	}
+	if isDigits(num) {
+		if num[0] == '0' && strings.Trim(num, "0_") != "" {
+			return "", "" // 017 is a syntax error
+		}
+		digits := strings.TrimLeft(strings.ReplaceAll(num, "_", ""), "0")
+		if digits == "" {
+			return "int", "0"
+		}
+		return "int", value[:len(value)-len(num)] + digits
+	}
+	if len(num) > 2 && num[0] == '0' && strings.ContainsRune("xXoObB", rune(num[1])) {
+		if n, err := strconv.ParseInt(num, 0, 64); err == nil {
+			return "int", strconv.FormatInt(sign(value)*n, 10)
+		}
+		return "int", ""
+	}
+	if strings.HasSuffix(num, "j") || strings.HasSuffix(num, "J") {
+		return "complex", ""
+	}
+	if x, err := strconv.ParseFloat(strings.ReplaceAll(num, "_", ""), 64); err == nil && !strings.ContainsAny(num, "xXpP") {
+		return "float", floatRepr(float64(sign(value)) * x)
+	}
+	return "", ""
+}
+
+func sign(value string) int64 {
+	if strings.HasPrefix(value, "-") {
+		return -1
+	}
+	return 1
+}
+
+func isDigits(s string) bool {
+	return strings.Trim(s, "0123456789_") == ""
+}
+
+// repr of a float: fixed notation for exponents in [-4, 16), like python
+func floatRepr(x float64) string {
+	if math.IsInf(x, 0) || math.IsNaN(x) {
+		return ""
+	}
+	e := strconv.FormatFloat(x, 'e', -1, 64)
+	exp, _ := strconv.Atoi(e[strings.IndexByte(e, 'e')+1:])
+	if exp < -4 || exp >= 16 {
+		return e
+	}
+	s := strconv.FormatFloat(x, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
+}
+
+// repr of a str: single quotes unless it has single quotes but no double ones
+func pyRepr(s string) string {
+	quote := "'"
+	if strings.Contains(s, "'") && !strings.Contains(s, `"`) {
+		quote = `"`
+	}
+	var b strings.Builder
+	b.WriteString(quote)
+	for _, r := range s {
+		switch {
+		case r == '\\':
+			b.WriteString(`\\`)
+		case string(r) == quote:
+			b.WriteString(`\` + quote)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r < 0x20 || r == 0x7f:
+			b.WriteString(`\x` + strconv.FormatInt(int64(r)+0x100, 16)[1:])
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteString(quote)
+	return b.String()
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
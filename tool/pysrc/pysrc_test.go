@@ -0,0 +1,213 @@
+package pysrc
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"github.com/goplus/llpyg/symbol"
+)
+
+const src = `"""Module doc.
+
+    Indented line.
+"""
+import os
+from typing import overload
+
+__all__ = ["f", "g", "Foo", "PI"]
+__all__ += ["NAME", "sq"]
+__all__.extend(["alias", "Bar", "OPT"])
+
+PI: float = 3.14
+NAME = 'it\'s'
+BIG = 1_000; HEX = 0x10
+OPT = None
+a = b = [1, 2]
+x.y = 1
+if a == b: pass
+
+@overload
+def f(a: int) -> int: ...
+@overload
+def f(a: str) -> str: ...
+def f(a):
+    '''Doc of f.'''
+    def inner(): ...
+    return a
+
+try:
+    from fast import g
+except ImportError:
+    async def g(x, /, *,
+                key=lambda v: v,  # comment
+                ) -> bytes:
+        return x
+else:
+    def g(y): ...
+
+sq = lambda n, p=2: n ** p
+alias = f
+
+@dataclass
+class Foo(Base, metaclass=ABCMeta):
+    """Doc of Foo."""
+    x: int = 0
+    def __init__(self, x): ...
+    @property
+    def size(self): ...
+    @classmethod
+    def create(cls, n: int = 1) -> "Foo": ...
+    @staticmethod
+    def check(v): ...
+    class Inner:
+        def hidden(self): ...
+
+Bar = Foo
+`
+
+func TestScan(t *testing.T) {
+	f := Scan(src)
+	if f.Doc != "Module doc.\n\nIndented line." {
+		t.Fatalf("Doc = %q", f.Doc)
+	}
+	if want := []string{"f", "g", "Foo", "PI", "NAME", "sq", "alias", "Bar", "OPT"}; !reflect.DeepEqual(f.All, want) {
+		t.Fatalf("All = %q", f.All)
+	}
+	var funcs []string
+	for _, fn := range f.Funcs {
+		funcs = append(funcs, fn.Name+fn.Sig)
+	}
+	want := []string{"f(a)", "g(x, /, *, key=lambda v: v) -> bytes", "sq(n, p=2)", "alias(a)"}
+	if !reflect.DeepEqual(funcs, want) {
+		t.Fatalf("Funcs = %q", funcs)
+	}
+	if fn := f.Funcs[0]; fn.Doc != "Doc of f." || !reflect.DeepEqual(fn.Overloads, []string{"(a: int) -> int", "(a: str) -> str"}) {
+		t.Fatalf("f = %+v", fn)
+	}
+	var vars []string
+	for _, v := range f.Vars {
+		vars = append(vars, fmt.Sprintf("%s:%s=%s", v.Name, v.Annotation, v.Value))
+	}
+	want = []string{"PI:float=3.14", `NAME:='it\'s'`, "BIG:=1_000", "HEX:=0x10", "OPT:=None", "a:=[1, 2]", "b:=[1, 2]"}
+	if !reflect.DeepEqual(vars, want) {
+		t.Fatalf("Vars = %q", vars)
+	}
+	if len(f.Classes) != 2 || f.Classes[1].Name != "Bar" {
+		t.Fatalf("Classes = %v", f.Classes)
+	}
+	foo := f.Classes[0]
+	if foo.Doc != "Doc of Foo." || !reflect.DeepEqual(foo.Bases, []string{"Base"}) || !reflect.DeepEqual(foo.Decorators, []string{"dataclass"}) {
+		t.Fatalf("Foo = %+v", foo)
+	}
+	var methods []string
+	for _, fn := range foo.Methods {
+		methods = append(methods, fn.Name+fn.Sig)
+	}
+	want = []string{"__init__(self, x)", `create(cls, n: int = 1) -> "Foo"`, "check(v)"}
+	if !reflect.DeepEqual(methods, want) {
+		t.Fatalf("Foo.Methods = %q", methods)
+	}
+}
+
+func TestModule(t *testing.T) {
+	mod := Scan(src).Module("demo", "demo.py")
+	if !mod.Static || mod.File != "demo.py" {
+		t.Fatalf("mod = %+v", mod)
+	}
+	if len(mod.Functions) != 4 || len(mod.Classes) != 2 {
+		t.Fatalf("Functions = %v, Classes = %v", mod.Functions, mod.Classes)
+	}
+	f := mod.Functions[0]
+	if f.Type != "function" || len(f.Sigs) != 2 || f.Sig != f.Sigs[0] || f.Return != "int" || len(f.Params) != 1 || f.Params[0].Annotation != "int" {
+		t.Fatalf("f = %+v", f)
+	}
+	g := mod.Functions[1]
+	if g.Return != "bytes" || g.Params[0].Kind != symbol.PositionalOnly || g.Params[1].Kind != symbol.KeywordOnly {
+		t.Fatalf("g = %+v", g)
+	}
+	foo := mod.Classes[0]
+	if len(foo.Methods) != 1 || len(foo.ClassMethods) != 1 || len(foo.StaticMethods) != 1 {
+		t.Fatalf("Foo = %+v", foo)
+	}
+	if create := foo.ClassMethods[0]; create.Sig != `(n: int = 1) -> "Foo"` || len(create.Params) != 1 {
+		t.Fatalf("Foo.create = %+v", create)
+	}
+	var vars []string
+	for _, v := range mod.Variables {
+		vars = append(vars, v.Name+":"+v.Type+"="+v.Repr)
+	}
+	if want := []string{"PI:float=3.14", `NAME:str="it's"`, "OPT:NoneType=None"}; !reflect.DeepEqual(vars, want) {
+		t.Fatalf("Variables = %q", vars)
+	}
+}
+
+func TestLiteral(t *testing.T) {
+	cases := [][3]string{
+		{"1_000", "int", "1000"},
+		{"-0x10", "int", "-16"},
+		{"00", "int", "0"},
+		{"1e3", "float", "1000.0"},
+		{"-2.50", "float", "-2.5"},
+		{"1e-05", "float", "1e-05"},
+		{"1e20", "float", "1e+20"},
+		{"2j", "complex", ""},
+		{`"a\tb"`, "str", `'a\tb'`},
+		{`b"a"`, "bytes", ""},
+		{`f"{x}"`, "", ""},
+		{"017", "", ""},
+		{"...", "", ""},
+		{"foo()", "", ""},
+	}
+	for _, c := range cases {
+		if typ, repr := literal(c[0]); typ != c[1] || repr != c[2] {
+			t.Fatalf("literal(%s) = %s, %s, want %s, %s", c[0], typ, repr, c[1], c[2])
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	paths := []string{"testdata/missing", "testdata/site"}
+	cases := map[string]string{
+		"purelib":      "testdata/site/purelib/__init__.py",
+		"purelib.util": "testdata/site/purelib/util.py",
+		"purelib.none": "",
+	}
+	for name, want := range cases {
+		if got := Find(name, paths); got != filepath.FromSlash(want) {
+			t.Fatalf("Find(%s) = %q, want %q", name, got, want)
+		}
+	}
+	if got, want := Modules("purelib", 2, paths), []string{"purelib", "purelib.sub", "purelib.util"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Modules = %v, want %v", got, want)
+	}
+	if got := Modules("purelib", 1, paths); len(got) != 1 {
+		t.Fatalf("Modules = %v", got)
+	}
+}
+
+type failDumper struct{}
+
+func (failDumper) Dump(moduleName string) (symbol.Module, error) {
+	return symbol.Module{}, fmt.Errorf("pydump %s failed: ModuleNotFoundError", moduleName)
+}
+
+func TestDumper(t *testing.T) {
+	d := &Dumper{Dumper: failDumper{}, Paths: []string{"testdata/site"}}
+	mod, err := d.Dump("purelib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mod.Static || len(mod.Functions) != 1 || len(mod.Classes) != 1 || len(mod.Variables) != 1 {
+		t.Fatalf("mod = %+v", mod)
+	}
+	if greet := mod.Functions[0]; greet.Doc != "Greet someone." || greet.Return != "str" || greet.Params[1].Default != "'!'" {
+		t.Fatalf("greet = %+v", greet)
+	}
+	if methods := mod.Classes[0].Methods; len(methods) != 2 || methods[1].Sig != "(self, loud=False)" {
+		t.Fatalf("Greeter.Methods = %v", methods)
+	}
+	if _, err = d.Dump("nonexistent"); err == nil {
+		t.Fatal("Dump(nonexistent) should fail")
+	}
+}
@@ -0,0 +1,323 @@
+package pysrc
+
+import (
+	"strings"
+	"github.com/goplus/llpyg/tool/pydoc"
+)
+
+// File is what static analysis finds at the top level of a .py file.
+type File struct {
+	Doc     string
+	All     []string // names in __all__, nil if not defined
+	Funcs   []*Func
+	Classes []*Class
+	Vars    []*Var
+}
+
+// Func is a function or method defined by def or lambda.
+type Func struct {
+	Name       string
+	Sig        string   // e.g. (a: int, b=1) -> str
+	Doc        string
+	Decorators []string // names, e.g. classmethod for @classmethod
+	Overloads  []string // sigs of the @overload defs before it
+}
+
+// Class is a top-level class, with the methods defined in its body.
+type Class struct {
+	Name       string
+	Doc        string
+	Bases      []string // as written, e.g. abc.ABC
+	Decorators []string
+	Methods    []*Func
+}
+
+// Var is a name bound by a top-level assignment.
+type Var struct {
+	Name       string
+	Annotation string // e.g. int of `x: int = 1`
+	Value      string // as written
+}
+
+type blockKind int
+
+const (
+	blockOther blockKind = iota // if, else, try and other transparent blocks
+	blockClass
+	blockDef
+)
+
+type block struct {
+	indent int
+	kind   blockKind
+	class  *Class
+}
+
+// Scan finds the top-level defs, classes and assignments of python source
+// without running it. Definitions in if and try blocks count, the first
+// one of a name wins, as only one of them is usually taken.
+func Scan(src string) *File {
+	f := &File{}
+	var stack []block
+	var decorators []string
+	overloads := make(map[string][]string) // sigs of pending @overload defs, by name
+	funcs := make(map[string]*Func)
+	classes := make(map[string]*Class)
+	vars := make(map[string]bool)
+	var doc *string // where the next string literal goes, if it is a docstring
+	doc = &f.Doc
+	for _, line := range Lines(src) {
+		for len(stack) > 0 && stack[len(stack)-1].indent >= line.Indent {
+			stack = stack[:len(stack)-1]
+		}
+		text := line.Text
+		if doc != nil {
+			if s, ok := stringLiteral(text); ok {
+				*doc = pydoc.Clean(s)
+			}
+			doc = nil
+		}
+		// top level, or the body of a top-level class
+		top, class := true, (*Class)(nil)
+		for _, b := range stack {
+			switch b.kind {
+			case blockDef:
+				top = false
+			case blockClass:
+				if class != nil {
+					top = false
+				}
+				class = b.class
+			}
+		}
+		if strings.HasPrefix(text, "@") {
+			decorators = append(decorators, DecoratorName(text))
+			continue
+		}
+		switch {
+		case strings.HasPrefix(text, "def "), strings.HasPrefix(text, "async def "):
+			name, sig, hasBody, ok := ParseDef(text)
+			fn := &Func{Name: name, Sig: sig, Decorators: decorators}
+			if ok && top {
+				key := name
+				if class != nil {
+					key = class.Name + "." + name
+				}
+				switch {
+				case hasDecorator(decorators, "overload"):
+					overloads[key] = append(overloads[key], sig)
+				case funcs[key] != nil:
+				case class != nil:
+					if !hasDecorator(decorators, "property") && !hasDecorator(decorators, "cached_property") {
+						fn.Overloads, funcs[key] = overloads[key], fn
+						class.Methods = append(class.Methods, fn)
+					}
+				default:
+					fn.Overloads, funcs[key] = overloads[key], fn
+					f.Funcs = append(f.Funcs, fn)
+				}
+			}
+			if hasBody {
+				stack = append(stack, block{indent: line.Indent, kind: blockDef})
+				doc = &fn.Doc
+			}
+		case strings.HasPrefix(text, "class "):
+			name, bases, hasBody := ParseClass(text)
+			cls := &Class{Name: name, Bases: bases, Decorators: decorators}
+			if top && class == nil && classes[name] == nil {
+				classes[name] = cls
+				f.Classes = append(f.Classes, cls)
+			}
+			if hasBody {
+				stack = append(stack, block{indent: line.Indent, kind: blockClass, class: cls})
+				doc = &cls.Doc
+			}
+		case strings.HasSuffix(text, ":"): // if, else, try, with ...
+			stack = append(stack, block{indent: line.Indent, kind: blockOther})
+		case top && class == nil:
+			for _, stmt := range SplitCode(text, ';') {
+				f.assign(strings.TrimSpace(stmt), funcs, classes, vars)
+			}
+		}
+		decorators = nil
+	}
+	return f
+}
+
+// a top-level simple statement binding names:
+//
+//	x = 1
+//	x: int = 1
+//	a = b = lambda x: x
+//	__all__ = ["a"], __all__ += [...], __all__.extend([...])
+func (f *File) assign(stmt string, funcs map[string]*Func, classes map[string]*Class, vars map[string]bool) {
+	if strings.HasPrefix(stmt, "__all__.extend(") || strings.HasPrefix(stmt, "__all__.append(") {
+		if open := strings.IndexByte(stmt, '('); MatchingParen(stmt, open) == len(stmt)-1 {
+			f.All = append(f.All, stringList(stmt[open+1:len(stmt)-1])...)
+		}
+		return
+	}
+	parts := splitAssign(stmt)
+	if len(parts) < 2 {
+		if pos := strings.Index(stmt, "+="); pos > 0 && strings.TrimSpace(stmt[:pos]) == "__all__" {
+			f.All = append(f.All, stringList(stmt[pos+2:])...)
+		}
+		return
+	}
+	value := strings.TrimSpace(parts[len(parts)-1])
+	for i, target := range parts[:len(parts)-1] {
+		annotation := ""
+		if pos := IndexCode(target, ':'); pos >= 0 && i == 0 {
+			target, annotation = target[:pos], strings.TrimSpace(target[pos+1:])
+		}
+		name := strings.TrimSpace(target)
+		if !isIdent(name) {
+			continue
+		}
+		if name == "__all__" {
+			f.All = append([]string{}, stringList(value)...)
+			continue
+		}
+		if funcs[name] != nil || classes[name] != nil || vars[name] {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(value, "lambda ") || strings.HasPrefix(value, "lambda:"):
+			fn := &Func{Name: name, Sig: lambdaSig(value)}
+			funcs[name] = fn
+			f.Funcs = append(f.Funcs, fn)
+		case funcs[value] != nil: // alias
+			fn := *funcs[value]
+			fn.Name = name
+			funcs[name] = &fn
+			f.Funcs = append(f.Funcs, &fn)
+		case classes[value] != nil:
+			cls := *classes[value]
+			cls.Name = name
+			classes[name] = &cls
+			f.Classes = append(f.Classes, &cls)
+		default:
+			vars[name] = true
+			f.Vars = append(f.Vars, &Var{Name: name, Annotation: annotation, Value: value})
+		}
+	}
+}
+
+// split a = b = 1 at the =s out of brackets and strings, but not at
+// ==, <=, +=, := and the other operators ending with =
+func splitAssign(stmt string) (parts []string) {
+	depth, last := 0, 0
+	for i := 0; i < len(stmt); i++ {
+		switch c := stmt[i]; c {
+		case '\'', '"':
+			i = SkipString(stmt, i) - 1
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case '=':
+			if depth > 0 {
+				continue
+			}
+			if i+1 < len(stmt) && stmt[i+1] == '=' {
+				i++
+				continue
+			}
+			if i > 0 && strings.IndexByte("=<>!+-*/%&|^@:~", stmt[i-1]) >= 0 {
+				continue
+			}
+			if strings.HasPrefix(strings.TrimSpace(stmt[last:i]), "lambda") {
+				i = len(stmt) // defaults of lambda params
+				continue
+			}
+			parts = append(parts, stmt[last:i])
+			last = i + 1
+		}
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	return append(parts, stmt[last:])
+}
+
+// lambda a, b=1: a + b -> (a, b=1)
+func lambdaSig(value string) string {
+	params := strings.TrimSpace(strings.TrimPrefix(value, "lambda"))
+	if colon := IndexCode(params, ':'); colon >= 0 {
+		params = strings.TrimSpace(params[:colon])
+	}
+	return "(" + params + ")"
+}
+
+// the string items of a list or tuple literal, e.g. ["a", 'b']
+func stringList(value string) (list []string) {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 || !strings.Contains("[(", value[:1]) {
+		return nil
+	}
+	for _, item := range SplitCode(value[1:len(value)-1], ',') {
+		if s, ok := stringLiteral(strings.TrimSpace(item)); ok {
+			list = append(list, s)
+		}
+	}
+	return
+}
+
+// the value of a single string literal like "abc" or r'\d', ok is false
+// for other expressions and for bytes and f-strings
+func stringLiteral(text string) (s string, ok bool) {
+	quote := strings.IndexAny(text, `'"`)
+	if quote < 0 || quote > 2 || SkipString(text, quote) != len(text) {
+		return "", false
+	}
+	prefix := strings.ToLower(text[:quote])
+	if prefix != "" && prefix != "r" && prefix != "u" {
+		return "", false
+	}
+	q := 1
+	if strings.HasPrefix(text[quote:], strings.Repeat(text[quote:quote+1], 3)) {
+		q = 3
+	}
+	if len(text)-quote < 2*q {
+		return "", false
+	}
+	s = text[quote+q : len(text)-q]
+	if prefix != "r" {
+		s = unescape(s)
+	}
+	return s, true
+}
+
+var escapes = strings.NewReplacer(
+	"\\\n", "", `\\`, `\`, `\'`, `'`, `\"`, `"`, `\n`, "\n", `\t`, "\t", `\r`, "\r",
+)
+
+// the common escapes of a string literal, others are kept as written
+func unescape(s string) string {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s
+	}
+	return escapes.Replace(s)
+}
+
+func isIdent(name string) bool {
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c != '_' && !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') && c < 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+func hasDecorator(decorators []string, name string) bool {
+	for _, d := range decorators {
+		if d == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,251 @@
+package pysrc
+
+import (
+	"strings"
+)
+
+// Line is a logical line of python source, without comments.
+type Line struct {
+	Indent int
+	Text   string // spaces of joined lines collapsed, see cleanSpaces
+}
+
+// Lines returns the logical lines of python source, a line continues
+// while brackets are open or after a backslash.
+func Lines(src string) (lines []Line) {
+	var text strings.Builder
+	indent, depth, start := 0, 0, true
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		if start {
+			// indent of a new logical line
+			indent = 0
+			for i < len(src) && (src[i] == ' ' || src[i] == '\t') {
+				if src[i] == '\t' {
+					indent += 8 - indent%8
+				} else {
+					indent++
+				}
+				i++
+			}
+			if i >= len(src) {
+				break
+			}
+			c, start = src[i], false
+		}
+		switch c {
+		case '#':
+			for i+1 < len(src) && src[i+1] != '\n' {
+				i++
+			}
+		case '\'', '"':
+			end := SkipString(src, i)
+			text.WriteString(src[i:end])
+			i = end - 1
+		case '\\':
+			if i+1 < len(src) && src[i+1] == '\n' {
+				i++
+				text.WriteByte(' ')
+			} else {
+				text.WriteByte(c)
+			}
+		case '\n', '\r':
+			if depth > 0 {
+				text.WriteByte(' ')
+				continue
+			}
+			if s := strings.TrimSpace(text.String()); s != "" {
+				lines = append(lines, Line{indent, cleanSpaces(s)})
+			}
+			text.Reset()
+			start = true
+		default:
+			switch c {
+			case '(', '[', '{':
+				depth++
+			case ')', ']', '}':
+				depth--
+			}
+			text.WriteByte(c)
+		}
+	}
+	if s := strings.TrimSpace(text.String()); s != "" {
+		lines = append(lines, Line{indent, cleanSpaces(s)})
+	}
+	return
+}
+
+// collapse the spaces of joined lines: (\n    a,\n) -> (a)
+func cleanSpaces(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\'' || c == '"':
+			end := SkipString(s, i)
+			b.WriteString(s[i:end])
+			i = end - 1
+			continue
+		case c == ' ' || c == '\t':
+			j := i
+			for j < len(s) && (s[j] == ' ' || s[j] == '\t') {
+				j++
+			}
+			out := b.String()
+			if j < len(s) && strings.IndexByte(")]}", s[j]) >= 0 {
+				if strings.HasSuffix(out, ",") && s[j] != '}' { // trailing comma
+					b.Reset()
+					b.WriteString(out[:len(out)-1])
+				}
+			} else if !strings.HasSuffix(out, "(") && !strings.HasSuffix(out, "[") {
+				b.WriteByte(' ')
+			}
+			i = j - 1
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// MatchingParen returns the index of the ) closing the ( at open, or -1.
+func MatchingParen(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '\'', '"':
+			i = SkipString(s, i) - 1
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// IndexCode returns the index of c out of brackets and strings, or -1.
+func IndexCode(s string, c byte) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\'', '"':
+			i = SkipString(s, i) - 1
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case c:
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// SplitCode splits s at sep out of brackets and strings.
+func SplitCode(s string, sep byte) (parts []string) {
+	for {
+		pos := IndexCode(s, sep)
+		if pos < 0 {
+			return append(parts, s)
+		}
+		parts = append(parts, s[:pos])
+		s = s[pos+1:]
+	}
+}
+
+// SkipString returns the end offset of the string literal starting at the quote at pos.
+func SkipString(s string, pos int) int {
+	quote := s[pos : pos+1]
+	if strings.HasPrefix(s[pos:], strings.Repeat(quote, 3)) {
+		quote = s[pos : pos+3]
+	}
+	for i := pos + len(quote); i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.HasPrefix(s[i:], quote) {
+			return i + len(quote)
+		}
+	}
+	return len(s)
+}
+
+// DecoratorName returns the name of a decorator line, e.g. @typing.overload -> overload.
+// The setter and deleter of a property are property.
+func DecoratorName(text string) string {
+	name := strings.TrimSpace(text[1:])
+	if pos := strings.IndexByte(name, '('); pos >= 0 {
+		name = name[:pos]
+	}
+	if pos := strings.LastIndexByte(name, '.'); pos >= 0 {
+		if strings.HasSuffix(name, ".setter") || strings.HasSuffix(name, ".deleter") {
+			return "property"
+		}
+		name = name[pos+1:]
+	}
+	return name
+}
+
+// ParseDef parses a def line: def name(params) -> ret: ...
+// sig is the params and the return annotation, e.g. (a: int) -> str.
+// ok is false if the line is not a complete def.
+func ParseDef(text string) (name, sig string, hasBody, ok bool) {
+	text = strings.TrimPrefix(strings.TrimPrefix(text, "async "), "def ")
+	open := strings.IndexByte(text, '(')
+	if open < 0 {
+		return
+	}
+	close := MatchingParen(text, open)
+	if close < 0 {
+		return
+	}
+	sig = text[open : close+1]
+	rest := strings.TrimSpace(text[close+1:])
+	colon := IndexCode(rest, ':')
+	if colon < 0 {
+		return
+	}
+	if ret := strings.TrimSpace(rest[:colon]); strings.HasPrefix(ret, "->") {
+		sig += " -> " + strings.TrimSpace(ret[2:])
+	}
+	name = strings.TrimSpace(text[:open])
+	return name, sig, strings.TrimSpace(rest[colon+1:]) == "", true
+}
+
+// ParseClass parses a class line: class Name(Base, metaclass=Meta): ...
+// Keyword arguments like metaclass are not bases.
+func ParseClass(text string) (name string, bases []string, hasBody bool) {
+	text = strings.TrimPrefix(text, "class ")
+	end := strings.IndexAny(text, "(:[")
+	if end < 0 {
+		return "", nil, false
+	}
+	name = strings.TrimSpace(text[:end])
+	rest := text[end:]
+	if rest[0] == '[' { // PEP 695 type params
+		if close := MatchingParen(rest, 0); close >= 0 {
+			rest = rest[close+1:]
+		}
+	}
+	if strings.HasPrefix(rest, "(") {
+		close := MatchingParen(rest, 0)
+		if close < 0 {
+			return name, nil, false
+		}
+		for _, base := range SplitCode(rest[1:close], ',') {
+			if base = strings.TrimSpace(base); base != "" && IndexCode(base, '=') < 0 && !strings.HasPrefix(base, "*") {
+				bases = append(bases, base)
+			}
+		}
+		rest = rest[close+1:]
+	}
+	colon := IndexCode(rest, ':')
+	return name, bases, colon >= 0 && strings.TrimSpace(rest[colon+1:]) == ""
+}
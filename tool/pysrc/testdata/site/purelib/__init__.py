@@ -0,0 +1,29 @@
+"""A pure python package that fails to import."""
+
+import missing_dependency
+
+from .util import helper
+
+__all__ = ["greet", "Greeter", "VERSION"]
+
+
+def greet(name: str, punctuation: str = "!") -> str:
+    """Greet someone."""
+    return "Hello, " + name + punctuation
+
+
+def _hidden():
+    pass
+
+
+class Greeter:
+    """Greets people."""
+
+    def __init__(self, name):
+        self.name = name
+
+    def greet(self, loud=False):
+        return greet(self.name)
+
+
+VERSION = "1.0"
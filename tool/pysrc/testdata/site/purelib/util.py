@@ -0,0 +1,2 @@
+def helper(x, *args, **kwargs):
+    return x
@@ -0,0 +1,212 @@
+// Package pystub reads PEP 561 stub files (.pyi) as a signature source.
+// Stubs are looked up next to the module, in <package>-stubs packages,
+// and in the given stub dirs, e.g. a typeshed checkout.
+package pystub
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"github.com/goplus/llpyg/symbol"
+	"github.com/goplus/llpyg/tool/pygen"
+	"github.com/goplus/llpyg/tool/pysig"
+	"github.com/goplus/llpyg/tool/pysrc"
+)
+
+// Dumper adds the signatures in stub files to the symbols dumped by
+// another Dumper. Stubs are preferred over the signatures taken from docs,
+// but not over the ones from inspect, which are exact for python code.
+type Dumper struct {
+	pygen.Dumper
+	Paths []string // stub dirs searched after the dirs of the module
+
+	stubs map[string]*stubFile // cache by module name, nil if not found
+}
+
+type stubFile struct {
+	*Stub
+	pkg string // package of relative imports
+}
+
+// max depth of following imports, e.g. numpy.sum is imported into
+// numpy/__init__.pyi from numpy/_core/fromnumeric.pyi
+const maxImportDepth = 3
+
+func (d *Dumper) Dump(moduleName string) (mod symbol.Module, err error) {
+	mod, err = d.Dumper.Dump(moduleName)
+	if err != nil {
+		return
+	}
+	roots := d.roots(&mod)
+	stub := d.load(moduleName, roots)
+	if stub == nil {
+		return mod, nil
+	}
+	for _, sym := range mod.Functions {
+		setSig(sym, d.lookup(stub, roots, sym.Name, 0, func(s *Stub, name string) []*Def {
+			return s.Funcs[name]
+		}), false)
+	}
+	for _, cls := range mod.Classes {
+		methods := d.lookupClass(stub, roots, cls.Name, 0)
+		if methods == nil {
+			continue
+		}
+		for _, sym := range cls.Methods {
+			setSig(sym, methods[sym.Name], false)
+		}
+		// pydump gets classmethods bound to the class, without cls
+		for _, sym := range cls.ClassMethods {
+			setSig(sym, methods[sym.Name], true)
+		}
+		for _, sym := range cls.StaticMethods {
+			setSig(sym, methods[sym.Name], false)
+		}
+	}
+	return mod, nil
+}
+
+// set the signatures of a symbol without an inspect signature from its
+// defs, without their first param if bound
+func setSig(sym *symbol.Symbol, defs []*Def, bound bool) {
+	if sym.Params != nil || len(defs) == 0 {
+		return
+	}
+	sigs := make([]string, len(defs))
+	for i, def := range defs {
+		sigs[i] = def.Sig
+		if bound {
+			sigs[i] = pysrc.DropFirstParam(def.Sig)
+		}
+	}
+	sig := pysig.ParseSignature(sigs[0])
+	params := make([]*symbol.Param, len(sig.Args))
+	for i, arg := range sig.Args {
+		params[i] = &symbol.Param{Name: arg.Name, Kind: arg.Kind, Annotation: arg.Type, Default: arg.DefVal}
+	}
+	sym.Sig, sym.Sigs, sym.Params, sym.Return = sigs[0], sigs, params, sig.Return
+}
+
+// defs of name in stub, following imports
+func (d *Dumper) lookup(stub *stubFile, roots []string, name string, depth int, get func(*Stub, string) []*Def) []*Def {
+	if defs := get(stub.Stub, name); defs != nil {
+		return defs
+	}
+	if imp, ok := stub.Imports[name]; ok && depth < maxImportDepth {
+		if from := d.load(resolveImport(imp.Module, stub.pkg), roots); from != nil {
+			return d.lookup(from, roots, imp.Name, depth+1, get)
+		}
+	}
+	return nil
+}
+
+// methods of class name in stub, following imports
+func (d *Dumper) lookupClass(stub *stubFile, roots []string, name string, depth int) map[string][]*Def {
+	if methods := stub.Classes[name]; methods != nil {
+		return methods
+	}
+	if imp, ok := stub.Imports[name]; ok && depth < maxImportDepth {
+		if from := d.load(resolveImport(imp.Module, stub.pkg), roots); from != nil {
+			return d.lookupClass(from, roots, imp.Name, depth+1)
+		}
+	}
+	return nil
+}
+
+// .mod of package a.b -> a.b.mod, ..mod -> a.mod
+func resolveImport(module, pkg string) string {
+	dots := len(module) - len(strings.TrimLeft(module, "."))
+	if dots == 0 {
+		return module
+	}
+	parts := strings.Split(pkg, ".")
+	if n := len(parts) - (dots - 1); n > 0 {
+		parts = parts[:n]
+	} else {
+		parts = nil
+	}
+	if rest := module[dots:]; rest != "" {
+		parts = append(parts, rest)
+	}
+	return strings.Join(parts, ".")
+}
+
+func (d *Dumper) load(moduleName string, roots []string) *stubFile {
+	if stub, ok := d.stubs[moduleName]; ok {
+		return stub
+	}
+	if d.stubs == nil {
+		d.stubs = make(map[string]*stubFile)
+	}
+	var stub *stubFile
+	if file := Find(moduleName, roots); file != "" {
+		if data, err := os.ReadFile(file); err == nil {
+			pkg := moduleName
+			if filepath.Base(file) != "__init__.pyi" {
+				if pos := strings.LastIndexByte(moduleName, '.'); pos >= 0 {
+					pkg = moduleName[:pos]
+				} else {
+					pkg = ""
+				}
+			}
+			stub = &stubFile{Parse(string(data)), pkg}
+		}
+	}
+	d.stubs[moduleName] = stub
+	return stub
+}
+
+// dirs to find stubs in: the dir of the top-level package, e.g. site-packages,
+// then Paths. The stdlib and stubs/* dirs of a typeshed checkout are added.
+func (d *Dumper) roots(mod *symbol.Module) (roots []string) {
+	if mod.File != "" {
+		dir := filepath.Dir(mod.File)
+		up := strings.Count(mod.Name, ".")
+		if strings.HasPrefix(filepath.Base(mod.File), "__init__.") {
+			up++
+		}
+		for i := 0; i < up; i++ {
+			dir = filepath.Dir(dir)
+		}
+		roots = append(roots, dir)
+	}
+	for _, path := range d.Paths {
+		roots = append(roots, path)
+		if stdlib := filepath.Join(path, "stdlib"); isDir(stdlib) {
+			roots = append(roots, stdlib)
+		}
+		dirs, _ := filepath.Glob(filepath.Join(path, "stubs", "*"))
+		roots = append(roots, dirs...)
+	}
+	return
+}
+
+// Find returns the stub file of a module in roots, "" if not found:
+//
+//	<root>/a/b.pyi
+//	<root>/a/b/__init__.pyi
+//	<root>/a-stubs/b.pyi
+//	<root>/a-stubs/b/__init__.pyi
+func Find(moduleName string, roots []string) string {
+	parts := strings.Split(moduleName, ".")
+	stubsPkg := append([]string{parts[0] + "-stubs"}, parts[1:]...)
+	for _, root := range roots {
+		for _, path := range [][]string{parts, stubsPkg} {
+			base := filepath.Join(append([]string{root}, path...)...)
+			for _, file := range []string{base + ".pyi", filepath.Join(base, "__init__.pyi")} {
+				if len(path) == 1 && path[0] == stubsPkg[0] && !strings.HasSuffix(file, "__init__.pyi") {
+					continue // a-stubs.pyi
+				}
+				if info, err := os.Stat(file); err == nil && !info.IsDir() {
+					return file
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,207 @@
+package pystub
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"github.com/goplus/llpyg/symbol"
+)
+
+const stubSrc = `import sys
+from typing import overload
+from numpy._core.fromnumeric import sum as sum, mean
+from ._impl import (
+    Model,
+    fit,  # comment
+)
+
+if sys.version_info >= (3, 10):
+    @overload
+    def f(a: int) -> int: ...
+    @overload
+    def f(a: str = "#(") -> str: ...
+else:
+    def f(a): ...
+
+def long(
+    a: int,
+    b: Callable[[int], str] = ...,
+    *args: int,
+) -> dict[str, int]: ...
+
+async def fetch(url: str) -> bytes: ...
+
+def with_body(x):
+    def inner(y): ...
+    return x
+
+class Foo(Base[int], metaclass=ABCMeta):
+    x: int
+    def __init__(self, x: int) -> None: ...
+    @property
+    def size(self) -> int: ...
+    @size.setter
+    def size(self, value: int) -> None: ...
+    @classmethod
+    def create(cls) -> Foo: ...
+    class Inner:
+        def hidden(self): ...
+    if sys.platform == "win32":
+        def run(self, cmd: str) -> int: ...
+    else:
+        def run(self, cmd: str, shell: bool = ...) -> int: ...
+
+class Empty: ...
+`
+
+func TestParse(t *testing.T) {
+	stub := Parse(stubSrc)
+	sigs := func(defs []*Def) (list []string) {
+		for _, def := range defs {
+			list = append(list, def.Sig)
+		}
+		return
+	}
+	funcs := map[string][]string{
+		"f":         {"(a: int) -> int", `(a: str = "#(") -> str`},
+		"long":      {"(a: int, b: Callable[[int], str] = ..., *args: int) -> dict[str, int]"},
+		"fetch":     {"(url: str) -> bytes"},
+		"with_body": {"(x)"},
+	}
+	if len(stub.Funcs) != len(funcs) {
+		t.Fatalf("Funcs = %v", stub.Funcs)
+	}
+	for name, want := range funcs {
+		if got := sigs(stub.Funcs[name]); !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: sigs = %q, want %q", name, got, want)
+		}
+	}
+	if !stub.Funcs["f"][0].Overload || stub.Funcs["long"][0].Overload {
+		t.Fatal("Overload not set")
+	}
+	methods := map[string][]string{
+		"__init__": {"(self, x: int) -> None"},
+		"create":   {"(cls) -> Foo"},
+		"run":      {"(self, cmd: str) -> int"},
+	}
+	foo := stub.Classes["Foo"]
+	if len(foo) != len(methods) || stub.Classes["Empty"] == nil || stub.Classes["Inner"] != nil {
+		t.Fatalf("Classes = %v", stub.Classes)
+	}
+	for name, want := range methods {
+		if got := sigs(foo[name]); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Foo.%s: sigs = %q, want %q", name, got, want)
+		}
+	}
+	imports := map[string]Import{
+		"sum":      {"numpy._core.fromnumeric", "sum"},
+		"mean":     {"numpy._core.fromnumeric", "mean"},
+		"Model":    {"._impl", "Model"},
+		"fit":      {"._impl", "fit"},
+		"overload": {"typing", "overload"},
+	}
+	if len(stub.Imports) != len(imports) {
+		t.Fatalf("Imports = %v", stub.Imports)
+	}
+	for name, want := range imports {
+		if got := stub.Imports[name]; got == nil || *got != want {
+			t.Fatalf("Imports[%s] = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	d := &Dumper{Paths: []string{"testdata/typeshed"}}
+	roots := d.roots(&symbol.Module{Name: "ext", File: "testdata/site/ext/__init__.py"})
+	cases := map[string]string{
+		"ext":       "testdata/site/ext/__init__.pyi",
+		"ext._impl": "testdata/site/ext/_impl.pyi",
+		"other":     "testdata/site/other-stubs/__init__.pyi",
+		"mathx":     "testdata/typeshed/stdlib/mathx.pyi",
+		"requests":  "testdata/typeshed/stubs/requests/requests/__init__.pyi",
+		"missing":   "",
+	}
+	for name, want := range cases {
+		if got := Find(name, roots); got != filepath.FromSlash(want) {
+			t.Fatalf("Find(%s) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestResolveImport(t *testing.T) {
+	cases := [][3]string{
+		{"numpy.linalg", "numpy", "numpy.linalg"},
+		{"._impl", "ext", "ext._impl"},
+		{"..core", "a.b", "a.core"},
+		{".", "a.b", "a.b"},
+	}
+	for _, c := range cases {
+		if got := resolveImport(c[0], c[1]); got != c[2] {
+			t.Fatalf("resolveImport(%s, %s) = %s, want %s", c[0], c[1], got, c[2])
+		}
+	}
+}
+
+type mapDumper map[string]symbol.Module
+
+func (d mapDumper) Dump(moduleName string) (symbol.Module, error) {
+	mod, ok := d[moduleName]
+	if !ok {
+		return mod, fmt.Errorf("no module %s", moduleName)
+	}
+	return mod, nil
+}
+
+func TestDumper(t *testing.T) {
+	inspected := []*symbol.Param{{Name: "name", Kind: symbol.PositionalOrKeyword}}
+	d := &Dumper{Dumper: mapDumper{"ext": {
+		Name: "ext",
+		File: "testdata/site/ext/__init__.py",
+		Functions: []*symbol.Symbol{
+			{Name: "add", Sig: "(*args, **kwargs)"},
+			{Name: "hello", Sig: "(name)", Params: inspected},
+			{Name: "gone", Sig: "(*args, **kwargs)"},
+		},
+		Classes: []*symbol.Class{{
+			Name:         "Model",
+			Methods:      []*symbol.Symbol{{Name: "fit", Sig: "(self, x, y=None)"}},
+			ClassMethods: []*symbol.Symbol{{Name: "load"}, {Name: "create"}},
+		}},
+	}}}
+	mod, err := d.Dump("ext")
+	if err != nil {
+		t.Fatal(err)
+	}
+	add := mod.Functions[0]
+	if add.Sig != "(x: int, y: int, /) -> int" || len(add.Sigs) != 2 || add.Return != "int" {
+		t.Fatalf("add = %+v", add)
+	}
+	if len(add.Params) != 2 || add.Params[0].Kind != symbol.PositionalOnly || add.Params[0].Annotation != "int" {
+		t.Fatalf("add.Params = %v", add.Params)
+	}
+	if hello := mod.Functions[1]; hello.Sig != "(name)" || !reflect.DeepEqual(hello.Params, inspected) {
+		t.Fatalf("inspect signature of hello is replaced: %+v", hello)
+	}
+	if gone := mod.Functions[2]; gone.Params != nil {
+		t.Fatalf("gone = %+v", gone)
+	}
+	fit := mod.Classes[0].Methods[0]
+	if fit.Sig != "(self, x: list[float], y: list[float] | None = None, **params: object) -> Model" {
+		t.Fatalf("fit = %+v", fit)
+	}
+	if fit.Params[3].Kind != symbol.VarKeyword || fit.Params[2].Default != "None" {
+		t.Fatalf("fit.Params = %v", fit.Params)
+	}
+	// classmethods are bound to the class
+	if load := mod.Classes[0].ClassMethods[0]; load.Sig != "(path: str) -> Model" || load.Params[0].Name != "path" {
+		t.Fatalf("load = %+v", load)
+	}
+	create := mod.Classes[0].ClassMethods[1]
+	if !reflect.DeepEqual(create.Sigs, []string{"() -> Model", "(alpha: float) -> Model"}) || len(create.Params) != 0 {
+		t.Fatalf("create = %+v", create)
+	}
+	if _, err = d.Dump("nonexistent"); err == nil {
+		t.Fatal("Dump(nonexistent) should fail")
+	}
+}
@@ -0,0 +1,160 @@
+package pystub
+
+import (
+	"strings"
+	"github.com/goplus/llpyg/tool/pysrc"
+)
+
+// Def is a function or method defined in a stub file.
+type Def struct {
+	Name     string
+	Sig      string // e.g. (a: int, b: str = ...) -> bool
+	Overload bool   // decorated with @overload
+}
+
+// Import is a name imported from another module, e.g. by
+// `from numpy._core.fromnumeric import sum`.
+type Import struct {
+	Module string // as written, e.g. ._core.fromnumeric for a relative import
+	Name   string
+}
+
+// Stub is the content of a stub file we care about.
+type Stub struct {
+	Funcs   map[string][]*Def            // module functions, overloads in order
+	Classes map[string]map[string][]*Def // module classes, by method name
+	Imports map[string]*Import           // module level names imported from other modules
+}
+
+type blockKind int
+
+const (
+	blockOther blockKind = iota // if, else, try and other transparent blocks
+	blockClass
+	blockDef
+)
+
+type block struct {
+	indent int
+	kind   blockKind
+	name   string // class name
+}
+
+// Parse parses the def lines of a stub file, including the ones in
+// if sys.version_info blocks. Of several defs of a name, only the first
+// one or the first run of @overload defs is kept, the others are for other
+// python versions or platforms.
+func Parse(src string) *Stub {
+	stub := &Stub{
+		Funcs:   make(map[string][]*Def),
+		Classes: make(map[string]map[string][]*Def),
+		Imports: make(map[string]*Import),
+	}
+	var stack []block
+	var decorators []string
+	lastDef := "" // name of the last def in the current block, to group @overload defs
+	for _, line := range pysrc.Lines(src) {
+		for len(stack) > 0 && stack[len(stack)-1].indent >= line.Indent {
+			stack = stack[:len(stack)-1]
+			lastDef = ""
+		}
+		text := line.Text
+		inDef, classes := false, []string{}
+		for _, b := range stack {
+			switch b.kind {
+			case blockDef:
+				inDef = true
+			case blockClass:
+				classes = append(classes, b.name)
+			}
+		}
+		if strings.HasPrefix(text, "@") {
+			decorators = append(decorators, pysrc.DecoratorName(text))
+			continue
+		}
+		switch {
+		case strings.HasPrefix(text, "def "), strings.HasPrefix(text, "async def "):
+			def, hasBody := parseDef(text, decorators)
+			if def != nil && !inDef && len(classes) <= 1 && !isProperty(decorators) {
+				defs := stub.Funcs
+				if len(classes) == 1 {
+					defs = stub.Classes[classes[0]]
+				}
+				if list := defs[def.Name]; list == nil || def.Overload && lastDef == def.Name {
+					defs[def.Name] = append(list, def)
+				}
+				lastDef = def.Name
+			}
+			if hasBody {
+				stack = append(stack, block{indent: line.Indent, kind: blockDef})
+			}
+		case strings.HasPrefix(text, "class "):
+			name, _, hasBody := pysrc.ParseClass(text)
+			if !inDef && len(classes) == 0 && stub.Classes[name] == nil {
+				stub.Classes[name] = make(map[string][]*Def)
+			}
+			if hasBody {
+				stack = append(stack, block{indent: line.Indent, kind: blockClass, name: name})
+			}
+			lastDef = ""
+		case strings.HasPrefix(text, "from ") && len(stack) == 0:
+			parseImport(text, stub.Imports)
+			lastDef = ""
+		default:
+			if strings.HasSuffix(text, ":") { // if, else, try, with ...
+				stack = append(stack, block{indent: line.Indent, kind: blockOther})
+			}
+			lastDef = ""
+		}
+		decorators = decorators[:0]
+	}
+	return stub
+}
+
+func isProperty(decorators []string) bool {
+	for _, name := range decorators {
+		if name == "property" || name == "cached_property" {
+			return true
+		}
+	}
+	return false
+}
+
+func hasDecorator(decorators []string, name string) bool {
+	for _, d := range decorators {
+		if d == name {
+			return true
+		}
+	}
+	return false
+}
+
+// def name(params) -> ret: ...
+func parseDef(text string, decorators []string) (def *Def, hasBody bool) {
+	name, sig, hasBody, ok := pysrc.ParseDef(text)
+	if !ok {
+		return nil, false
+	}
+	return &Def{Name: name, Sig: sig, Overload: hasDecorator(decorators, "overload")}, hasBody
+}
+
+// from mod import a, b as c
+// from mod import (a, b)
+func parseImport(text string, imports map[string]*Import) {
+	text = strings.TrimPrefix(text, "from ")
+	pos := strings.Index(text, " import ")
+	if pos < 0 {
+		return
+	}
+	module := strings.TrimSpace(text[:pos])
+	names := strings.Trim(strings.TrimSpace(text[pos+len(" import "):]), "()")
+	for _, item := range strings.Split(names, ",") {
+		fields := strings.Fields(item)
+		switch {
+		case len(fields) == 1 && fields[0] != "*":
+			imports[fields[0]] = &Import{Module: module, Name: fields[0]}
+		case len(fields) == 3 && fields[1] == "as":
+			imports[fields[2]] = &Import{Module: module, Name: fields[0]}
+		}
+	}
+}
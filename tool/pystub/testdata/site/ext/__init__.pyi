@@ -0,0 +1,6 @@
+from ._impl import Model, add as add
+from .missing import gone
+
+__version__: str
+
+def hello(name: str) -> None: ...
@@ -0,0 +1,24 @@
+import sys
+from typing import overload
+
+@overload
+def add(x: int, y: int, /) -> int: ...
+@overload
+def add(x: float, y: float, /) -> float: ...
+
+class Model:
+    def __init__(self, alpha: float = ...) -> None: ...
+    def fit(
+        self,
+        x: list[float],
+        y: list[float] | None = None,
+        **params: object,
+    ) -> Model: ...
+    @classmethod
+    def load(cls, path: str) -> Model: ...
+    @overload
+    @classmethod
+    def create(cls) -> Model: ...
+    @overload
+    @classmethod
+    def create(cls, alpha: float) -> Model: ...
@@ -0,0 +1 @@
+def ping() -> bool: ...
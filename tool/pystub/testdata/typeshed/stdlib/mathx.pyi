@@ -0,0 +1 @@
+def floor(x: float, /) -> int: ...
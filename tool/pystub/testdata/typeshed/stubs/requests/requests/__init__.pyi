@@ -0,0 +1 @@
+def get(url: str, params: dict[str, str] | None = None, **kwargs) -> Response: ...